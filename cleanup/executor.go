@@ -0,0 +1,109 @@
+package cleanup
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/go-github/v24/github"
+)
+
+// Deleter removes releases from a repository (implemented by *ghapi.GitHub)
+type Deleter interface {
+	// RemoveRelease deletes a release and its tag
+	RemoveRelease(ctx context.Context, owner string, repo string, release *github.RepositoryRelease) error
+
+	// RemoveReleaseOnly deletes a release, but keeps its tag
+	RemoveReleaseOnly(ctx context.Context, owner string, repo string, release *github.RepositoryRelease) error
+}
+
+// Executor carries out the decisions for a repository, reporting progress through optional callbacks
+type Executor struct {
+	// Client removes the releases
+	Client Deleter
+
+	// Owner is the user or organization owning the repository
+	Owner string
+
+	// Repo is the name of the repository
+	Repo string
+
+	// Action is what happens to the releases (ActionDelete when empty)
+	Action string
+
+	// DryRun simulates the cleanup, without deleting anything
+	DryRun bool
+
+	// BeforeDelete is called before each release is deleted (optional)
+	BeforeDelete func(release *github.RepositoryRelease)
+
+	// AfterDelete is called after each release was deleted, with the error if it failed (optional)
+	AfterDelete func(release *github.RepositoryRelease, err error)
+}
+
+// Result is the outcome of executing the decisions for a repository
+type Result struct {
+	// Deleted are the releases that were deleted (or would have been, when simulating)
+	Deleted []*github.RepositoryRelease
+
+	// Failed are the releases that couldn't be deleted
+	Failed []*github.RepositoryRelease
+
+	// Remaining are the releases that weren't processed because the context was cancelled
+	Remaining []*github.RepositoryRelease
+
+	// Interrupted is true if the context was cancelled before all releases were processed
+	Interrupted bool
+}
+
+// Execute deletes the releases selected by the decisions in order, moving on to the next release when one fails.
+// Once the context is cancelled no further deletions are started, but the one in flight always runs to completion,
+// so a release is never left without its tag being handled. Nothing is deleted if the action isn't supported.
+func (executor *Executor) Execute(ctx context.Context, decisions Decisions) (Result, error) {
+	if err := validateAction(executor.Action); err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Deleted: make([]*github.RepositoryRelease, 0),
+		Failed:  make([]*github.RepositoryRelease, 0),
+	}
+
+	releases := decisions.Matched()
+	for index, release := range releases {
+		// Stop before starting the next deletion if we've been cancelled
+		if ctx.Err() != nil {
+			result.Remaining = releases[index:]
+			result.Interrupted = true
+			return result, nil
+		}
+
+		if executor.BeforeDelete != nil {
+			executor.BeforeDelete(release)
+		}
+		var err error
+		if !executor.DryRun {
+			err = executor.remove(release)
+		}
+		if err != nil {
+			result.Failed = append(result.Failed, release)
+		} else {
+			result.Deleted = append(result.Deleted, release)
+		}
+		if executor.AfterDelete != nil {
+			executor.AfterDelete(release, err)
+		}
+	}
+
+	return result, nil
+}
+
+// remove deletes a single release according to the action, without being cancellable
+func (executor *Executor) remove(release *github.RepositoryRelease) error {
+	switch executor.Action {
+	case ActionDeleteRelease:
+		return executor.Client.RemoveReleaseOnly(context.Background(), executor.Owner, executor.Repo, release)
+	case ActionDelete, "":
+		return executor.Client.RemoveRelease(context.Background(), executor.Owner, executor.Repo, release)
+	}
+	return errors.New("unsupported action \"" + executor.Action + "\"")
+}
@@ -0,0 +1,116 @@
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v24/github"
+)
+
+// testDeleter records the deletions and fails for the configured tags
+type testDeleter struct {
+	calls []string
+	fail  map[string]bool
+}
+
+func (deleter *testDeleter) RemoveRelease(ctx context.Context, owner string, repo string, release *github.RepositoryRelease) error {
+	return deleter.remove("release+tag "+release.GetTagName(), release)
+}
+
+func (deleter *testDeleter) RemoveReleaseOnly(ctx context.Context, owner string, repo string, release *github.RepositoryRelease) error {
+	return deleter.remove("release "+release.GetTagName(), release)
+}
+
+func (deleter *testDeleter) remove(call string, release *github.RepositoryRelease) error {
+	deleter.calls = append(deleter.calls, call)
+	if deleter.fail[release.GetTagName()] {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func TestExecutor(t *testing.T) {
+	now := time.Now()
+	decisions := Policy{FilterCount: Filter(1)}.Plan([]*github.RepositoryRelease{
+		newTestRelease("v3", now, 0),
+		newTestRelease("v2", now, 1),
+		newTestRelease("v1", now, 2),
+	})
+
+	deleter := &testDeleter{fail: map[string]bool{"v2": true}}
+	callbacks := make([]string, 0)
+	executor := &Executor{
+		Client:       deleter,
+		Action:       ActionDeleteRelease,
+		BeforeDelete: func(release *github.RepositoryRelease) { callbacks = append(callbacks, "before "+release.GetTagName()) },
+		AfterDelete: func(release *github.RepositoryRelease, err error) {
+			outcome := "ok"
+			if err != nil {
+				outcome = "failed"
+			}
+			callbacks = append(callbacks, "after "+release.GetTagName()+" "+outcome)
+		},
+	}
+	result, err := executor.Execute(context.Background(), decisions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deleter.calls) != 2 || deleter.calls[0] != "release v2" || deleter.calls[1] != "release v1" {
+		t.Errorf("unexpected deletions: %v", deleter.calls)
+	}
+	if len(callbacks) != 4 || callbacks[1] != "after v2 failed" || callbacks[3] != "after v1 ok" {
+		t.Errorf("unexpected callbacks: %v", callbacks)
+	}
+	if len(result.Deleted) != 1 || len(result.Failed) != 1 || result.Remaining != nil || result.Interrupted {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestExecutorStopsWhenCancelled(t *testing.T) {
+	now := time.Now()
+	decisions := Policy{FilterCount: Filter(0)}.Plan([]*github.RepositoryRelease{
+		newTestRelease("v2", now, 0),
+		newTestRelease("v1", now, 1),
+	})
+
+	// Cancel the context while the first release is being deleted, which still completes
+	ctx, cancel := context.WithCancel(context.Background())
+	deleter := &testDeleter{}
+	executor := &Executor{
+		Client:       deleter,
+		BeforeDelete: func(release *github.RepositoryRelease) { cancel() },
+	}
+	result, err := executor.Execute(ctx, decisions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deleter.calls) != 1 || deleter.calls[0] != "release+tag v2" {
+		t.Errorf("unexpected deletions: %v", deleter.calls)
+	}
+	if !result.Interrupted || len(result.Deleted) != 1 || len(result.Remaining) != 1 || result.Remaining[0].GetTagName() != "v1" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestExecutorDryRun(t *testing.T) {
+	decisions := Policy{FilterCount: Filter(0)}.Plan([]*github.RepositoryRelease{newTestRelease("v1", time.Now(), 0)})
+
+	deleter := &testDeleter{}
+	result, err := (&Executor{Client: deleter, DryRun: true}).Execute(context.Background(), decisions)
+	if err != nil || len(deleter.calls) != 0 || len(result.Deleted) != 1 {
+		t.Errorf("expected a simulated deletion, got calls %v, result %+v and error %v", deleter.calls, result, err)
+	}
+}
+
+func TestExecutorRejectsUnknownAction(t *testing.T) {
+	decisions := Policy{FilterCount: Filter(0)}.Plan([]*github.RepositoryRelease{newTestRelease("v1", time.Now(), 0)})
+
+	deleter := &testDeleter{}
+	if _, err := (&Executor{Client: deleter, Action: "archive"}).Execute(context.Background(), decisions); err == nil || len(deleter.calls) != 0 {
+		t.Errorf("expected an unknown action to be refused without deleting anything, got calls %v and error %v", deleter.calls, err)
+	}
+}
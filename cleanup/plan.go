@@ -0,0 +1,89 @@
+package cleanup
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v24/github"
+)
+
+// Decision is what the policy decided for a single release
+type Decision struct {
+	// Release is the release the decision is about
+	Release *github.RepositoryRelease
+
+	// Delete is true if the release is cleaned up
+	Delete bool
+
+	// Protected is true if the policy explicitly protects the release
+	Protected bool
+
+	// Reason explains why the release is cleaned up or protected, continuing a sentence starting with the release
+	// (eg. "falls outside of the day filter by 3 day(s)", empty if the release is simply kept)
+	Reason string
+}
+
+// Decisions are the decisions for all releases of a repository, newest first
+type Decisions []Decision
+
+// Matched returns the releases that are cleaned up, in order
+func (decisions Decisions) Matched() []*github.RepositoryRelease {
+	releases := make([]*github.RepositoryRelease, 0)
+	for _, decision := range decisions {
+		if decision.Delete {
+			releases = append(releases, decision.Release)
+		}
+	}
+	return releases
+}
+
+// Plan decides which of the releases (ordered newest to oldest, as returned by the GitHub API) are cleaned up
+func (policy Policy) Plan(releases []*github.RepositoryRelease) Decisions {
+	planner := NewPlanner(policy, time.Now())
+	decisions := make(Decisions, 0, len(releases))
+	for _, release := range releases {
+		decisions = append(decisions, planner.Decide(release))
+	}
+	return decisions
+}
+
+// Planner makes the decisions one release at a time, so releases can be streamed instead of fetched up front
+type Planner struct {
+	policy Policy
+	now    time.Time
+	count  int64
+}
+
+// NewPlanner creates a planner for the policy, measuring the age of releases relative to now
+func NewPlanner(policy Policy, now time.Time) *Planner {
+	return &Planner{policy: policy, now: now}
+}
+
+// Decide decides if the next release (ordered newest to oldest) is cleaned up
+func (planner *Planner) Decide(release *github.RepositoryRelease) Decision {
+	planner.count++
+	decision := Decision{Release: release}
+
+	// Protected releases are never cleaned up, but still count towards the count filter
+	if protected, reason := planner.policy.Protects(release); protected {
+		decision.Protected = true
+		decision.Reason = "is protected because " + reason
+		return decision
+	}
+
+	// Apply the count based filter
+	if filterCount := planner.policy.FilterCount; filterCount != nil && planner.count > *filterCount {
+		decision.Delete = true
+		decision.Reason = "falls outside of the count filter by " + strconv.FormatInt(planner.count-*filterCount, 10) + " release(s)"
+		return decision
+	}
+
+	// Apply the day based filter (the number of days since release is rounded)
+	daysSinceRelease := int64(math.Round(planner.now.Sub(release.GetCreatedAt().Time).Hours() / 24))
+	if filterDays := planner.policy.FilterDays; filterDays != nil && daysSinceRelease > *filterDays {
+		decision.Delete = true
+		decision.Reason = "falls outside of the day filter by " + strconv.FormatInt(daysSinceRelease-*filterDays, 10) + " day(s)"
+	}
+	return decision
+}
@@ -0,0 +1,71 @@
+package cleanup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v24/github"
+)
+
+// newTestRelease creates a release with a tag, created the given number of days before now
+func newTestRelease(tag string, now time.Time, days int) *github.RepositoryRelease {
+	return &github.RepositoryRelease{
+		TagName:   &tag,
+		CreatedAt: &github.Timestamp{Time: now.AddDate(0, 0, -days)},
+	}
+}
+
+func TestPlanner(t *testing.T) {
+	now := time.Now()
+	releases := []*github.RepositoryRelease{
+		newTestRelease("v1.3.0", now, 1),
+		newTestRelease("v1.2.0", now, 10),
+		newTestRelease("stable-1", now, 20),
+		newTestRelease("v1.1.0", now, 30),
+		newTestRelease("v1.0.0", now, 40),
+	}
+
+	// The count filter applies first, while protected releases still count towards it
+	planner := NewPlanner(Policy{FilterDays: Filter(5), FilterCount: Filter(3), KeepTags: []string{"stable-*"}}, now)
+	decisions := make(Decisions, 0)
+	for _, release := range releases {
+		decisions = append(decisions, planner.Decide(release))
+	}
+
+	expected := []struct {
+		delete    bool
+		protected bool
+		reason    string
+	}{
+		{false, false, ""},
+		{true, false, "falls outside of the day filter by 5 day(s)"},
+		{false, true, "is protected because its tag matches stable-*"},
+		{true, false, "falls outside of the count filter by 1 release(s)"},
+		{true, false, "falls outside of the count filter by 2 release(s)"},
+	}
+	for index, decision := range decisions {
+		if decision.Delete != expected[index].delete || decision.Protected != expected[index].protected || decision.Reason != expected[index].reason {
+			t.Errorf("%s: expected %+v, got %+v", decision.Release.GetTagName(), expected[index], decision)
+		}
+	}
+
+	matched := decisions.Matched()
+	if len(matched) != 3 || matched[0].GetTagName() != "v1.2.0" || matched[2].GetTagName() != "v1.0.0" {
+		t.Errorf("unexpected matched releases: %v", matched)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	now := time.Now()
+	releases := []*github.RepositoryRelease{newTestRelease("v2", now, 0), newTestRelease("v1", now, 0)}
+
+	decisions := Policy{FilterCount: Filter(1)}.Plan(releases)
+	if len(decisions) != 2 || decisions[0].Delete || !decisions[1].Delete {
+		t.Errorf("expected only the oldest release to be cleaned up, got %+v", decisions)
+	}
+
+	// Unset filters are turned off, so the zero value cleans up nothing
+	if matched := (Policy{}).Plan(releases).Matched(); len(matched) != 0 {
+		t.Errorf("expected the zero value policy to clean up nothing, got %v", matched)
+	}
+}
@@ -0,0 +1,95 @@
+// Package cleanup decides which GitHub releases a retention policy cleans up, and carries out the cleanup.
+package cleanup
+
+import (
+	"errors"
+	"path"
+	"strconv"
+
+	"github.com/google/go-github/v24/github"
+)
+
+// Supported cleanup actions
+const (
+	// ActionDelete deletes both the release and its tag
+	ActionDelete = "delete"
+
+	// ActionDeleteRelease deletes the release, but keeps its tag
+	ActionDeleteRelease = "delete-release"
+)
+
+// ErrMissingFilter is returned when a policy has neither a day nor a count filter
+var ErrMissingFilter = errors.New("missing at least one filter")
+
+// Policy is a retention policy, deciding which releases of a repository are cleaned up
+// (the zero value has no filters, so it cleans up nothing and doesn't validate)
+type Policy struct {
+	// FilterDays cleans up releases created more than this many days ago (nil turns the filter off)
+	FilterDays *int64
+
+	// FilterCount cleans up all but this many of the newest releases (nil turns the filter off)
+	FilterCount *int64
+
+	// KeepTags protects releases whose tag matches any of these glob patterns
+	KeepTags []string
+
+	// KeepPrereleases protects prereleases
+	KeepPrereleases bool
+
+	// KeepDrafts protects draft releases
+	KeepDrafts bool
+
+	// Action is what happens to the releases being cleaned up (ActionDelete when empty)
+	Action string
+}
+
+// Filter returns a filter value for a policy (eg. Policy{FilterCount: cleanup.Filter(10)})
+func Filter(value int64) *int64 {
+	return &value
+}
+
+// Validate checks that the policy has at least one filter, a supported action and valid tag patterns
+func (policy Policy) Validate() error {
+	if policy.FilterDays == nil && policy.FilterCount == nil {
+		return ErrMissingFilter
+	}
+	if policy.FilterDays != nil && *policy.FilterDays < 0 {
+		return errors.New("invalid day filter " + strconv.FormatInt(*policy.FilterDays, 10) + " (must not be negative)")
+	}
+	if policy.FilterCount != nil && *policy.FilterCount < 0 {
+		return errors.New("invalid count filter " + strconv.FormatInt(*policy.FilterCount, 10) + " (must not be negative)")
+	}
+	if err := validateAction(policy.Action); err != nil {
+		return err
+	}
+	for _, pattern := range policy.KeepTags {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.New("invalid tag pattern \"" + pattern + "\"")
+		}
+	}
+	return nil
+}
+
+// validateAction checks that the action is supported (empty meaning ActionDelete)
+func validateAction(action string) error {
+	if action != "" && action != ActionDelete && action != ActionDeleteRelease {
+		return errors.New("unsupported action \"" + action + "\" (use \"" + ActionDelete + "\" or \"" + ActionDeleteRelease + "\")")
+	}
+	return nil
+}
+
+// Protects checks if the policy protects a release from being cleaned up, returning the reason if it does
+func (policy Policy) Protects(release *github.RepositoryRelease) (bool, string) {
+	if policy.KeepDrafts && release.GetDraft() {
+		return true, "it is a draft"
+	}
+	if policy.KeepPrereleases && release.GetPrerelease() {
+		return true, "it is a prerelease"
+	}
+	for _, pattern := range policy.KeepTags {
+		if matched, _ := path.Match(pattern, release.GetTagName()); matched {
+			return true, "its tag matches " + pattern
+		}
+	}
+	return false, ""
+}
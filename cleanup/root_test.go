@@ -0,0 +1,43 @@
+package cleanup
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v24/github"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	if err := (Policy{Action: ActionDelete}).Validate(); err != ErrMissingFilter {
+		t.Errorf("expected ErrMissingFilter without filters, got %v", err)
+	}
+	if err := (Policy{FilterDays: Filter(1), Action: "archive"}).Validate(); err == nil {
+		t.Error("expected an error for an unsupported action")
+	}
+	if err := (Policy{FilterDays: Filter(1), Action: ActionDelete, KeepTags: []string{"["}}).Validate(); err == nil {
+		t.Error("expected an error for an invalid tag pattern")
+	}
+	if err := (Policy{FilterCount: Filter(-5)}).Validate(); err == nil {
+		t.Error("expected an error for a negative filter")
+	}
+	if err := (Policy{FilterCount: Filter(10)}).Validate(); err != nil {
+		t.Errorf("expected a policy without an action to be valid, got %v", err)
+	}
+	if err := (Policy{FilterDays: Filter(0)}).Validate(); err != nil {
+		t.Errorf("expected an explicit zero filter to be valid, got %v", err)
+	}
+}
+
+func TestPolicyProtects(t *testing.T) {
+	policy := Policy{KeepTags: []string{"v1.*"}, KeepPrereleases: true}
+	tag, otherTag, prerelease := "v1.2.0", "v2.0.0", true
+
+	if protected, _ := policy.Protects(&github.RepositoryRelease{TagName: &tag}); !protected {
+		t.Error("expected a release matching a tag pattern to be protected")
+	}
+	if protected, _ := policy.Protects(&github.RepositoryRelease{TagName: &otherTag, Prerelease: &prerelease}); !protected {
+		t.Error("expected a prerelease to be protected")
+	}
+	if protected, _ := policy.Protects(&github.RepositoryRelease{TagName: &otherTag}); protected {
+		t.Error("expected an unmatched release not to be protected")
+	}
+}
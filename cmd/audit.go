@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Didstopia/githubby/cleanup"
+	"github.com/Didstopia/githubby/ghapi"
+	"github.com/google/go-github/v24/github"
+)
+
+// The outcomes recorded in the audit log
+const (
+	auditOutcomeSucceeded = "succeeded"
+	auditOutcomeFailed    = "failed"
+)
+
+// auditEntry is a single destructive action recorded in the audit log (never containing any secrets)
+type auditEntry struct {
+	Timestamp   time.Time   `json:"timestamp"`
+	Actor       string      `json:"actor"`
+	Host        string      `json:"host"`
+	Repository  string      `json:"repository"`
+	Action      string      `json:"action"`
+	ReleaseID   int64       `json:"release_id"`
+	ReleaseName string      `json:"release_name,omitempty"`
+	Tag         string      `json:"tag"`
+	TagSHA      string      `json:"tag_sha,omitempty"`
+	Assets      []string    `json:"assets"`
+	Policy      auditPolicy `json:"policy"`
+	Reason      string      `json:"reason,omitempty"`
+	Outcome     string      `json:"outcome"`
+	Error       string      `json:"error,omitempty"`
+}
+
+// auditPolicy is the retention policy that triggered an action (filters that are turned off are null)
+type auditPolicy struct {
+	FilterDays      *int64   `json:"filter_days"`
+	FilterCount     *int64   `json:"filter_count"`
+	KeepTags        []string `json:"keep_tags,omitempty"`
+	KeepPrereleases bool     `json:"keep_prereleases"`
+	KeepDrafts      bool     `json:"keep_drafts"`
+}
+
+// auditLogger appends entries to the audit log as JSON lines
+type auditLogger struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// The audit log of the current run (nil if disabled, such as when simulating)
+var auditLog *auditLogger
+
+// openAuditLog opens the audit log for appending, creating it (and its directory) if needed
+func openAuditLog(path string) (*auditLogger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("unable to create the audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open the audit log: %w", err)
+	}
+	return &auditLogger{file: file}, nil
+}
+
+// Record appends an entry to the audit log, making sure it's written to disk (a nil audit log records nothing)
+func (audit *auditLogger) Record(entry auditEntry) error {
+	if audit == nil {
+		return nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// Write each entry with a single call, so concurrent runs appending to the same file don't interleave
+	audit.mutex.Lock()
+	defer audit.mutex.Unlock()
+	if _, err := audit.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("unable to write to the audit log: %w", err)
+	}
+	return audit.file.Sync()
+}
+
+// Close closes the audit log (a nil audit log is ignored)
+func (audit *auditLogger) Close() error {
+	if audit == nil {
+		return nil
+	}
+	return audit.file.Close()
+}
+
+// newAuditEntry describes the outcome of cleaning up a release
+func newAuditEntry(actor string, repository string, policy cleanup.Policy, release *github.RepositoryRelease, tagSHA string, reason string, err error) auditEntry {
+	assets := make([]string, 0, len(release.Assets))
+	for _, asset := range release.Assets {
+		assets = append(assets, asset.GetName())
+	}
+	entry := auditEntry{
+		Timestamp:   time.Now().UTC(),
+		Actor:       actor,
+		Host:        apiHost(),
+		Repository:  repository,
+		Action:      cleanup.ActionDelete,
+		ReleaseID:   release.GetID(),
+		ReleaseName: release.GetName(),
+		Tag:         release.GetTagName(),
+		TagSHA:      tagSHA,
+		Assets:      assets,
+		Policy: auditPolicy{
+			FilterDays:      policy.FilterDays,
+			FilterCount:     policy.FilterCount,
+			KeepTags:        policy.KeepTags,
+			KeepPrereleases: policy.KeepPrereleases,
+			KeepDrafts:      policy.KeepDrafts,
+		},
+		Reason:  reason,
+		Outcome: auditOutcomeSucceeded,
+	}
+	if policy.Action != "" {
+		entry.Action = policy.Action
+	}
+	if err != nil {
+		entry.Outcome = auditOutcomeFailed
+		entry.Error = err.Error()
+	}
+	return entry
+}
+
+// auditActor describes who performed the actions: the login of the token, or otherwise the GitHub App
+func auditActor(identity *ghapi.Identity) string {
+	if identity != nil && identity.Login != "" {
+		return identity.Login
+	}
+	if AppID != 0 {
+		return tokenSource
+	}
+	return "unknown"
+}
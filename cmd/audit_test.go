@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Didstopia/githubby/cleanup"
+	"github.com/Didstopia/githubby/ghapi"
+	"github.com/Didstopia/githubby/ghapi/ghapitest"
+	"github.com/google/go-github/v24/github"
+	"github.com/spf13/viper"
+)
+
+// readAuditLog reads back the entries of an audit log
+func readAuditLog(t *testing.T, path string) []auditEntry {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	entries := make([]auditEntry, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("expected a JSON line, got %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAuditLogAppends(t *testing.T) {
+	dir, err := ioutil.TempDir("", "githubby-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit", "audit.log")
+	for _, tag := range []string{"v1.0.0", "v1.0.1"} {
+		audit, err := openAuditLog(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := audit.Record(auditEntry{Tag: tag, Outcome: auditOutcomeSucceeded}); err != nil {
+			t.Fatal(err)
+		}
+		if err := audit.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries := readAuditLog(t, path)
+	if len(entries) != 2 || entries[0].Tag != "v1.0.0" || entries[1].Tag != "v1.0.1" {
+		t.Errorf("expected both entries to be appended, got %+v", entries)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected the audit log to only be readable by its owner, got %v (%v)", info.Mode(), err)
+	}
+
+	// Without an audit log nothing is recorded
+	var disabled *auditLogger
+	if err := disabled.Record(auditEntry{}); err != nil {
+		t.Errorf("expected a disabled audit log to record nothing, got %v", err)
+	}
+}
+
+func TestNewAuditEntry(t *testing.T) {
+	release := &github.RepositoryRelease{
+		ID:      github.Int64(42),
+		TagName: github.String("v1.0.0"),
+		Assets:  []github.ReleaseAsset{{Name: github.String("app.tar.gz")}, {Name: github.String("app.zip")}},
+	}
+	policy := cleanup.Policy{FilterDays: cleanup.Filter(30), KeepTags: []string{"*-lts"}}
+
+	entry := newAuditEntry("octocat", "acme/app", policy, release, "abc123", "falls outside of the day filter by 3 day(s)", nil)
+	if entry.Action != cleanup.ActionDelete || entry.Outcome != auditOutcomeSucceeded || entry.Error != "" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if entry.ReleaseID != 42 || entry.Tag != "v1.0.0" || entry.TagSHA != "abc123" || strings.Join(entry.Assets, ",") != "app.tar.gz,app.zip" {
+		t.Errorf("unexpected release details %+v", entry)
+	}
+	if *entry.Policy.FilterDays != 30 || entry.Policy.FilterCount != nil || strings.Join(entry.Policy.KeepTags, ",") != "*-lts" {
+		t.Errorf("unexpected policy %+v", entry.Policy)
+	}
+
+	policy.Action = cleanup.ActionDeleteRelease
+	entry = newAuditEntry("octocat", "acme/app", policy, release, "", "", errors.New("403 Forbidden"))
+	if entry.Action != cleanup.ActionDeleteRelease || entry.Outcome != auditOutcomeFailed || entry.Error != "403 Forbidden" {
+		t.Errorf("unexpected failed entry %+v", entry)
+	}
+}
+
+func TestAuditActor(t *testing.T) {
+	defer func(appID int64, source string) {
+		AppID, tokenSource = appID, source
+	}(AppID, tokenSource)
+
+	if actor := auditActor(&ghapi.Identity{Login: "octocat"}); actor != "octocat" {
+		t.Errorf("expected the login, got %q", actor)
+	}
+	AppID, tokenSource = 0, "flag or config file"
+	if actor := auditActor(nil); actor != "unknown" {
+		t.Errorf("expected an unknown actor, got %q", actor)
+	}
+	AppID, tokenSource = 123, "GitHub App 123"
+	if actor := auditActor(&ghapi.Identity{TokenType: ghapi.TokenTypeInstallation}); actor != "GitHub App 123" {
+		t.Errorf("expected the GitHub App, got %q", actor)
+	}
+}
+
+func TestCleanRepositoryRecordsAuditLog(t *testing.T) {
+	fake := ghapitest.NewFake()
+	now := time.Now()
+	for index, tag := range []string{"v1.0.2", "v1.0.1", "v1.0.0"} {
+		release := fake.AddRelease("acme", "app", tag, now.Add(-time.Duration(index)*time.Hour))
+		fake.AddAsset("acme", "app", release.GetID(), "app-"+tag+".tar.gz")
+	}
+	fake.Fail(ghapitest.OpDeleteRelease, ghapitest.FaultForbidden, 1)
+	server := ghapitest.NewServer(fake)
+	defer server.Close()
+
+	defer func(config *viper.Viper, baseURL string, token string, filterDays int64, filterCount int64, action string, audit *auditLogger) {
+		viperConfig, BaseURL, Token = config, baseURL, token
+		FilterDays, FilterCount, Action, auditLog = filterDays, filterCount, action, audit
+	}(viperConfig, BaseURL, Token, FilterDays, FilterCount, Action, auditLog)
+	viperConfig = viper.New()
+	viperConfig.SetConfigType("yaml")
+	if err := viperConfig.ReadConfig(bytes.NewBufferString("")); err != nil {
+		t.Fatal(err)
+	}
+	BaseURL, Token = server.BaseURL, "token"
+	FilterDays, FilterCount, Action = -1, 1, actionDelete
+
+	dir, err := ioutil.TempDir("", "githubby-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+	audit, err := openAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditLog = audit
+	result := cleanRepository(context.Background(), "acme/app")
+	audit.Close()
+	if result.err != nil {
+		t.Fatal(result.err)
+	}
+
+	// The first deletion fails, the second one succeeds
+	entries := readAuditLog(t, path)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %+v", entries)
+	}
+	failed, deleted := entries[0], entries[1]
+	if failed.Tag != "v1.0.1" || failed.Outcome != auditOutcomeFailed || !strings.Contains(failed.Error, "403") {
+		t.Errorf("unexpected failed entry %+v", failed)
+	}
+	if deleted.Tag != "v1.0.0" || deleted.Outcome != auditOutcomeSucceeded || deleted.Error != "" {
+		t.Errorf("unexpected deleted entry %+v", deleted)
+	}
+	if deleted.Actor != "octocat" || deleted.Repository != "acme/app" || deleted.Action != actionDelete || deleted.TagSHA == "" {
+		t.Errorf("unexpected actor, repository, action or tag SHA %+v", deleted)
+	}
+	if strings.Join(deleted.Assets, ",") != "app-v1.0.0.tar.gz" || *deleted.Policy.FilterCount != 1 || !strings.Contains(deleted.Reason, "count filter") {
+		t.Errorf("unexpected assets, policy or reason %+v", deleted)
+	}
+}
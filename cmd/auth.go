@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Didstopia/githubby/credentials"
+	"github.com/spf13/cobra"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage GitHub credentials",
+	Long:  `Manage the GitHub API tokens kept in the encrypted credential store`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Inject config file variables, but don't require a token or repository up front
+		logErrorAndExit(profileErr)
+		injectViper(viperConfig, cmd)
+	},
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Store a token in the encrypted credential store",
+	Long: `Store a GitHub API token for the current host in the encrypted credential store.
+The token is read from --token, or from the first line of stdin when omitted.
+The store is encrypted with the passphrase from the ` + credentials.PassphraseEnv + ` environment variable.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := credentialStore()
+		if err != nil {
+			logErrorAndExit(err)
+		}
+
+		// Read the token from stdin unless it was supplied as a flag
+		token := Token
+		if token == "" {
+			fmt.Fprintln(os.Stderr, "Paste your token for "+apiHost()+":")
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && line == "" {
+				logErrorAndExit(fmt.Errorf("unable to read token from stdin: %w", err))
+			}
+			token = strings.TrimSpace(line)
+		}
+		if token == "" {
+			logErrorAndExit(errors.New("missing token"))
+		}
+
+		if err := store.Set(apiHost(), token); err != nil {
+			logErrorAndExit(err)
+		}
+		fmt.Println("Stored token for", apiHost(), "in", store.Path)
+	},
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove a token from the encrypted credential store",
+	Long:  `Remove the GitHub API token for the current host from the encrypted credential store`,
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := credentialStore()
+		if err != nil {
+			logErrorAndExit(err)
+		}
+
+		deleted, err := store.Delete(apiHost())
+		if err != nil {
+			logErrorAndExit(err)
+		}
+		if deleted {
+			fmt.Println("Removed token for", apiHost(), "from", store.Path)
+		} else {
+			fmt.Println("No token stored for", apiHost())
+		}
+	},
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the current authentication status",
+	Long:  `Show who the current credentials authenticate as, along with the token type, scopes, expiry and host`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Resolve the credentials the same way every other command does
+		resolveCredentials()
+
+		// Create a new GitHub client
+		owner, err := installationOwner()
+		logErrorAndExit(err)
+		client, err := newClient(owner)
+		if err != nil {
+			logErrorAndExit(err)
+		}
+
+		identity, err := client.Identity(context.Background())
+		if err != nil {
+			logErrorAndExit(err)
+		}
+
+		fmt.Println("Host:        ", apiHost())
+		if identity.Login != "" {
+			fmt.Println("Logged in as:", identity.Login)
+		}
+		fmt.Println("Token type:  ", identity.TokenType)
+		fmt.Println("Token source:", tokenSource)
+		if identity.Scopes == nil {
+			fmt.Println("Scopes:       n/a (permissions are granted per repository)")
+		} else if len(identity.Scopes) == 0 {
+			fmt.Println("Scopes:       none")
+		} else {
+			fmt.Println("Scopes:      ", strings.Join(identity.Scopes, ", "))
+		}
+		if identity.Expiry == nil {
+			fmt.Println("Expires:      never")
+		} else {
+			fmt.Println("Expires:     ", identity.Expiry.Local().Format(time.RFC1123))
+		}
+	},
+}
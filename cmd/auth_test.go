@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Didstopia/githubby/credentials"
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// useTempCredentialStore points the credential store at a temporary home and config directory
+func useTempCredentialStore(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "githubby-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore := make(map[string]string)
+	for key, value := range map[string]string{
+		"HOME":                    dir,
+		"XDG_CONFIG_HOME":         filepath.Join(dir, ".config"),
+		credentials.PassphraseEnv: "correct horse battery staple",
+		"GITHUBBY_TOKEN":          "",
+		"GH_TOKEN":                "",
+		"GITHUB_TOKEN":            "",
+	} {
+		restore[key] = os.Getenv(key)
+		os.Setenv(key, value)
+	}
+	disableCache := homedir.DisableCache
+	homedir.DisableCache = true
+	return filepath.Join(dir, ".config", configDirName, xdgCredentialStoreFileName), func() {
+		for key, value := range restore {
+			os.Setenv(key, value)
+		}
+		homedir.DisableCache = disableCache
+		os.RemoveAll(dir)
+	}
+}
+
+func TestAuthLoginAndLogout(t *testing.T) {
+	path, restore := useTempCredentialStore(t)
+	defer restore()
+	defer func(token string, baseURL string) {
+		Token, BaseURL = token, baseURL
+	}(Token, BaseURL)
+	Token, BaseURL = "ghp_stored", ""
+
+	authLoginCmd.Run(authLoginCmd, nil)
+	store, err := credentialStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Path != path {
+		t.Errorf("expected the store next to the XDG config file, got %s", store.Path)
+	}
+	if token, err := store.Token(credentials.DefaultHost); err != nil || token != "ghp_stored" {
+		t.Errorf("expected the token to be stored, got %q (%v)", token, err)
+	}
+
+	// The stored token is looked up before the ones other tools manage
+	if token, source, err := credentialChain().Token(credentials.DefaultHost); err != nil || token != "ghp_stored" || source != store.Name() {
+		t.Errorf("expected the stored token, got %q from %q (%v)", token, source, err)
+	}
+
+	authLogoutCmd.Run(authLogoutCmd, nil)
+	if token, err := store.Token(credentials.DefaultHost); err != nil || token != "" {
+		t.Errorf("expected the token to be removed, got %q (%v)", token, err)
+	}
+}
+
+func TestCredentialChainOrder(t *testing.T) {
+	_, restore := useTempCredentialStore(t)
+	defer restore()
+	defer func(tokenFile string, gitCredential bool) {
+		TokenFile, GitCredential = tokenFile, gitCredential
+	}(TokenFile, GitCredential)
+
+	names := func() []string {
+		chain := credentialChain()
+		names := make([]string, 0, len(chain))
+		for _, provider := range chain {
+			names = append(names, provider.Name())
+		}
+		return names
+	}
+
+	TokenFile, GitCredential = "token.txt", false
+	expected := "environment,token file,encrypted store,gh cli"
+	if actual := names(); strings.Join(actual, ",") != expected {
+		t.Errorf("expected %s, got %s", expected, strings.Join(actual, ","))
+	}
+
+	// Git's credential helpers are only asked when enabled
+	TokenFile, GitCredential = "", true
+	expected = "environment,encrypted store,git credential,gh cli"
+	if actual := names(); strings.Join(actual, ",") != expected {
+		t.Errorf("expected %s, got %s", expected, strings.Join(actual, ","))
+	}
+}
@@ -1,16 +1,22 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"fmt"
-	"math"
+	"io"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/Didstopia/githubby/cleanup"
 	"github.com/Didstopia/githubby/ghapi"
-	"github.com/Didstopia/githubby/util"
 	"github.com/google/go-github/v24/github"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	pb "gopkg.in/cheggaaa/pb.v1"
 )
@@ -24,160 +30,455 @@ var FilterCount int64
 // The progress bar (only used when running non-verbosely)
 var progressBar *pb.ProgressBar
 
+// cleanResult tracks the outcome of cleaning up a single repository
+type cleanResult struct {
+	repository  string
+	total       int
+	matched     []*github.RepositoryRelease
+	deleted     []*github.RepositoryRelease
+	failed      []*github.RepositoryRelease
+	remaining   []*github.RepositoryRelease
+	interrupted bool
+	err         error
+}
+
 var cleanCmd = &cobra.Command{
 	Use:   "clean",
 	Short: "Filter and remove GitHub Releases",
-	Long:  `Use one or more filters to remove GitHub Releases`,
+	Long:  `Use one or more filters to remove GitHub Releases from one or more repositories`,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Track progress bar state
-		progressEnabled := !Verbose
+		// Cancel the run on SIGINT/SIGTERM, letting any in-flight deletion finish first
+		ctx, cancel := handleInterrupts()
+		defer cancel()
 
-		// Keep track of filter state
-		filterDaysEnabled := FilterDays != -1
-		filterCountEnabled := FilterCount != -1
+		// Parse the webhooks up front, so failures are reported and a broken template doesn't go unnoticed until after cleaning up
+		webhooks, err := newWebhooks()
+		logErrorAndExit(err)
+		startedAt := time.Now()
 
-		// Validate that at least one filter is being used
-		if !filterDaysEnabled && !filterCountEnabled {
-			err := errors.New("missing at least one filter flag (run with --help for more information)")
-			fmt.Println("Error:", err)
-			os.Exit(1)
+		// Collect the repositories from the flags, the repository file, the organization or user, or the current git checkout
+		repositories, err := collectRepositories(ctx)
+		notifyWebhooksAndExit(webhooks, startedAt, err)
+		if len(repositories) == 0 {
+			fmt.Println("No repositories matched the selectors, nothing to clean up")
+			return
 		}
 
-		// Validate the repository
-		owner, repo, err := util.ValidateGitHubRepository(Repository)
-		if err != nil {
-			fmt.Println("Error:", err)
-			os.Exit(1)
+		// Record every deletion in the audit log, if one is configured
+		if !DryRun && AuditLog != "" {
+			auditLog, err = openAuditLog(AuditLog)
+			notifyWebhooksAndExit(webhooks, startedAt, err)
 		}
 
-		if Verbose {
-			fmt.Println("Validation succeeded for repository", owner+"/"+repo)
+		// Clean up each repository in turn, stopping early if we're interrupted
+		results := make([]*cleanResult, 0, len(repositories))
+		for _, repository := range repositories {
+			if ctx.Err() != nil {
+				break
+			}
+			if len(repositories) > 1 {
+				fmt.Println("\n==> Cleaning up", repository)
+			}
+			result := cleanRepository(ctx, repository)
+			if result.err != nil {
+				log.WithField("repo", result.repository).WithError(result.err).Error("Unable to clean up the repository")
+			}
+			results = append(results, result)
 		}
 
-		// Create a new GitHub client
-		client, err := ghapi.NewGitHub(Token)
-		if err != nil {
-			fmt.Println("Error:", err)
-			os.Exit(1)
+		// Report the outcome
+		if len(repositories) > 1 {
+			printCleanSummary(results, repositories[len(results):])
+		} else if len(results) == 1 && results[0].interrupted && results[0].remaining != nil {
+			printInterruptSummary(results[0].deleted, results[0].failed, results[0].remaining)
 		}
-
-		// Notify the user
-		if !Verbose {
-			fmt.Println("\nFetching releases, please wait..")
+		if err := auditLog.Close(); err != nil {
+			log.WithError(err).Error("Unable to close the audit log")
 		}
-
-		// Fetch all releases for the repository
-		releases, err := client.GetReleases(owner, repo)
-		if err != nil {
-			fmt.Println("Error:", err)
-			os.Exit(1)
+		if len(webhooks) > 0 {
+			notifyWebhooks(webhooks, newWebhookSummary(results, repositories[len(results):], startedAt))
 		}
+		os.Exit(cleanExitCode(results, len(repositories)))
+	},
+}
 
-		if Verbose {
-			fmt.Println("Found", len(releases), "releases total")
-		}
+// cleanRepository applies the filters to a single repository and removes the matching releases
+func cleanRepository(ctx context.Context, repository string) *cleanResult {
+	result := &cleanResult{repository: repository}
+
+	// Track progress bar state
+	progressEnabled := !Verbose
+	progressBar = nil
+
+	// Validate the repository
+	owner, repo, err := parseRepository(repository)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	result.repository = owner + "/" + repo
+	repoLog := log.WithField("repo", result.repository)
+	repoLog.Debug("Validation succeeded for the repository")
 
-		if DryRun && Verbose {
-			fmt.Println("Dry run detected, simulating cleanup")
+	// Create a new GitHub client
+	client, err := newClient(owner)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	// Verify the token can actually delete releases before doing any work (only warn when simulating)
+	identity, err := client.Preflight(ctx, owner, repo)
+	if err != nil {
+		if !DryRun {
+			result.err = err
+			return result
 		}
+		repoLog.WithError(err).Warn("Preflight check failed, continuing with the simulated cleanup")
+	} else {
+		repoLog.WithFields(logrus.Fields{"login": identity.Login, "token_type": identity.TokenType}).Debug("Authenticated")
+	}
 
-		// Notify the user
-		if !Verbose {
-			fmt.Printf("Found %d release(s) total, applying filters..\n", len(releases))
+	// Fetch the policies from the repository and the organization's ".github" repository if enabled
+	var orgPolicy, repoPolicy *policyConfig
+	if RepositoryPolicy {
+		orgPolicy, repoPolicy, err = fetchRemotePolicies(ctx, client, owner, repo)
+		if err != nil {
+			result.err = err
+			return result
 		}
+	}
 
-		// Create a new array of releases that need cleanup
-		cleanupReleases := make([]*github.RepositoryRelease, 0)
+	// Resolve the retention policy for the repository and validate that at least one filter is being used
+	effectivePolicy, err := resolvePolicy(owner+"/"+repo, orgPolicy, repoPolicy)
+	if err != nil {
+		result.err = err
+		return result
+	}
 
-		// Loop through releases and check them against any enabled filters (newest to oldest)
-		for count, release := range releases {
-			// Parse the number of days since release (rounded up)
-			daysSinceRelease := int64(math.Round(time.Since(release.CreatedAt.Time).Hours() / 24))
+	// Notify the user
+	if !Verbose {
+		fmt.Println("\nFetching releases, please wait..")
+	}
 
-			// fmt.Println("Checking release", release.CreatedAt)
+	if DryRun {
+		repoLog.Debug("Dry run detected, simulating cleanup")
+	}
 
-			// Keep track of already added releases
-			alreadyAdded := false
+	// Stream the releases page by page and decide which ones to clean up (newest to oldest)
+	decisions := make(cleanup.Decisions, 0)
+	planner := cleanup.NewPlanner(effectivePolicy, time.Now())
+	iterator := client.IterateReleases(ctx, owner, repo, ghapi.ReleaseIteratorOptions{})
+	for iterator.Next() {
+		decision := planner.Decide(iterator.Release())
+		if decision.Reason != "" {
+			releaseLog(repoLog, decision.Release).WithField("created_at", decision.Release.GetCreatedAt().Time).Debug("Release " + decision.Reason)
+		}
+		decisions = append(decisions, decision)
+	}
+	cleanupReleases := decisions.Matched()
+	result.total = len(decisions)
+	result.matched = cleanupReleases
+	if err := iterator.Err(); err != nil {
+		if ctx.Err() != nil {
+			fmt.Println("\nInterrupted while fetching releases, nothing was deleted")
+			result.interrupted = true
+			return result
+		}
+		result.err = err
+		return result
+	}
 
-			// Apply the count based filter
-			if filterCountEnabled {
-				if !alreadyAdded {
-					if int64(count+1) > FilterCount {
-						if Verbose {
-							fmt.Println("Release created at", release.CreatedAt, "falls outside of count filter by", int64(count+1)-FilterCount, "release(s)")
-						}
-						cleanupReleases = append(cleanupReleases, release)
-						alreadyAdded = true
-					}
-				}
-			}
+	repoLog.WithField("total", len(decisions)).Debug("Fetched all releases")
 
-			// Apply the day based filter
-			if filterDaysEnabled {
-				if !alreadyAdded {
-					if daysSinceRelease > FilterDays {
-						if Verbose {
-							fmt.Println("Release created at", release.CreatedAt, "falls outside of day filter by", daysSinceRelease-FilterDays, "day(s)")
-						}
-						cleanupReleases = append(cleanupReleases, release)
-						alreadyAdded = true
-					}
-				}
-			}
-		}
+	// Notify the user
+	if !Verbose {
+		fmt.Printf("Found %d release(s) total\n", len(decisions))
+	}
 
-		// Notify the user
-		if !Verbose {
-			if !DryRun {
-				fmt.Printf("Found %d release(s) matching the filters, starting cleanup..\n\n", len(cleanupReleases))
-			} else {
-				fmt.Printf("Found %d release(s) matching the filters, starting simulated cleanup..\n\n", len(cleanupReleases))
-			}
+	// Notify the user
+	if !Verbose {
+		if !DryRun {
+			fmt.Printf("Found %d release(s) matching the filters, starting cleanup..\n\n", len(cleanupReleases))
+		} else {
+			fmt.Printf("Found %d release(s) matching the filters, starting simulated cleanup..\n\n", len(cleanupReleases))
 		}
+	}
 
-		// Create a new progress bar based on the total cleanup release count
-		if progressEnabled && len(cleanupReleases) > 0 {
-			progressBar = pb.StartNew(len(cleanupReleases))
-		}
+	// Create a new progress bar based on the total cleanup release count
+	if progressEnabled && len(cleanupReleases) > 0 {
+		progressBar = pb.StartNew(len(cleanupReleases))
+	}
 
-		if Verbose {
-			fmt.Println("Found", len(cleanupReleases), "releases that match cleanup filters")
+	repoLog.WithField("matched", len(cleanupReleases)).Debug("Found releases that match the cleanup filters")
+
+	// Keep track of why each release is cleaned up, for the audit log
+	reasons := make(map[int64]string, len(cleanupReleases))
+	for _, decision := range decisions {
+		if decision.Delete {
+			reasons[decision.Release.GetID()] = decision.Reason
 		}
+	}
 
-		// Run the actual cleanup process
-		for _, release := range cleanupReleases {
-			if Verbose {
-				fmt.Println("Cleaning up release at", release.CreatedAt)
-			}
+	// Run the actual cleanup process, where errors are simply logged before moving on to the next release
+	var deleteStart time.Time
+	var tagSHA string
+	executor := &cleanup.Executor{
+		Client: client,
+		Owner:  owner,
+		Repo:   repo,
+		Action: effectivePolicy.Action,
+		DryRun: DryRun,
+		BeforeDelete: func(release *github.RepositoryRelease) {
+			deleteStart = time.Now()
+			releaseLog(repoLog, release).WithField("action", effectivePolicy.Action).Debug("Cleaning up release")
 
-			// Remove the release
-			if !DryRun {
-				// If an error occurs, we'll simply log it and move on to the next one
-				err := client.RemoveRelease(owner, repo, release)
+			// Look up what the tag points to while it still exists, so it can be restored from the audit log
+			tagSHA = ""
+			if auditLog != nil && !DryRun {
+				sha, err := client.GetTagSHA(context.Background(), owner, repo, release.GetTagName())
 				if err != nil {
-					fmt.Println("Error deleting release:", err)
-					//os.Exit(1)
-				} else {
-					if Verbose {
-						fmt.Println("Successfully deleted release at", release.CreatedAt)
-					}
+					releaseLog(repoLog, release).WithError(err).Debug("Unable to look up the tag SHA")
 				}
-			} else {
-				if Verbose {
-					fmt.Println("Dry run enabled, simulating cleanup")
+				tagSHA = sha
+			}
+		},
+		AfterDelete: func(release *github.RepositoryRelease, err error) {
+			entry := releaseLog(repoLog, release).WithFields(logrus.Fields{
+				"action":   effectivePolicy.Action,
+				"duration": time.Since(deleteStart).Round(time.Millisecond).String(),
+			})
+			if !DryRun {
+				auditEntry := newAuditEntry(auditActor(identity), result.repository, effectivePolicy, release, tagSHA, reasons[release.GetID()], err)
+				if auditErr := auditLog.Record(auditEntry); auditErr != nil {
+					entry.WithError(auditErr).Error("Unable to record the cleanup in the audit log")
 				}
+			}
+			if err != nil {
+				entry.WithError(err).Error("Unable to clean up release")
+			} else if DryRun {
+				entry.Debug("Simulated cleaning up release")
+				// Pace the simulated cleanup, so its progress can still be followed
 				time.Sleep(time.Duration(100) * time.Millisecond)
+			} else {
+				entry.Debug("Cleaned up release")
 			}
 
 			// Increment the progress bar
 			if progressEnabled && progressBar != nil {
 				progressBar.Increment()
 			}
+		},
+	}
+	executed, err := executor.Execute(ctx, decisions)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	// Keep track of what was and wasn't cleaned up, so we can report it if we're interrupted
+	result.deleted = executed.Deleted
+	result.failed = executed.Failed
+	result.remaining = executed.Remaining
+	result.interrupted = executed.Interrupted
+	if result.interrupted {
+		return result
+	}
+
+	// Mark the progress bar as done
+	if progressEnabled && progressBar != nil {
+		progressBar.FinishPrint("\nSuccessfully cleaned up " + strconv.Itoa(len(result.deleted)) + " release(s)!")
+	}
+
+	return result
+}
+
+// collectRepositories returns the repositories to clean up: the repository flags, the repository file ("-" meaning stdin)
+// and the repositories discovered for the organization or user, or otherwise the repository detected from the current git checkout
+func collectRepositories(ctx context.Context) ([]string, error) {
+	repositories := make([]string, 0, len(Repositories))
+	repositories = append(repositories, Repositories...)
+
+	if RepositoryFile != "" {
+		var reader io.Reader = os.Stdin
+		if RepositoryFile != "-" {
+			file, err := os.Open(RepositoryFile)
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close()
+			reader = file
+		}
+		fileRepositories, err := readRepositoryList(reader)
+		if err != nil {
+			return nil, err
 		}
+		repositories = append(repositories, fileRepositories...)
+	}
 
-		// Mark the progress bar as done
-		if progressEnabled && progressBar != nil {
-			progressBar.FinishPrint("\nSuccessfully cleaned up " + strconv.Itoa(len(cleanupReleases)) + " release(s)!")
+	// Discover the repositories of the organization or user
+	selector := ghapi.RepositorySelector{
+		Org:             Org,
+		User:            User,
+		Topics:          Topics,
+		Names:           Names,
+		ExcludeArchived: ExcludeArchived,
+	}
+	if selector.Org != "" || selector.User != "" {
+		discoveredRepositories, err := discoverRepositories(ctx, selector)
+		if err != nil {
+			return nil, err
 		}
-	},
+		repositories = append(repositories, discoveredRepositories...)
+	} else if len(selector.Topics) > 0 || len(selector.Names) > 0 || selector.ExcludeArchived {
+		return nil, errors.New("the 'topic', 'name' and 'exclude-archived' selectors require 'org' or 'user'")
+	}
+
+	// Add the repositories listed in the config policies
+	if FromPolicies {
+		configRepositories, err := policyRepositories()
+		if err != nil {
+			return nil, err
+		}
+		repositories = append(repositories, configRepositories...)
+	}
+
+	// Detect the repository from the current git checkout if none were supplied
+	if len(repositories) == 0 && selector.Org == "" && selector.User == "" && !FromPolicies {
+		repository, err := detectRepository(Remote)
+		if err != nil {
+			return nil, errors.New("missing required argument 'repository' (unable to detect it from the current directory: " + err.Error() + ")")
+		}
+		repositories = append(repositories, repository)
+	}
+
+	return repositories, nil
+}
+
+// discoverRepositories returns the full names of the repositories matching the selector
+func discoverRepositories(ctx context.Context, selector ghapi.RepositorySelector) ([]string, error) {
+	owner := selector.Org
+	if owner == "" {
+		owner = selector.User
+	}
+
+	client, err := newClient(owner)
+	if err != nil {
+		return nil, err
+	}
+	discoveredRepositories, err := client.DiscoverRepositories(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithFields(logrus.Fields{"owner": owner, "count": len(discoveredRepositories)}).Debug("Discovered matching repositories")
+
+	repositories := make([]string, 0, len(discoveredRepositories))
+	for _, repository := range discoveredRepositories {
+		repositories = append(repositories, repository.GetFullName())
+	}
+	return repositories, nil
+}
+
+// releaseLog returns a log entry with the fields identifying a release
+func releaseLog(entry *logrus.Entry, release *github.RepositoryRelease) *logrus.Entry {
+	return entry.WithFields(logrus.Fields{"release_id": release.GetID(), "tag": release.GetTagName()})
+}
+
+// readRepositoryList reads one repository per line, skipping blank lines and # comments
+func readRepositoryList(reader io.Reader) ([]string, error) {
+	repositories := make([]string, 0)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		repositories = append(repositories, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return repositories, nil
+}
+
+// cleanExitCode aggregates the results into an exit code: 130 if interrupted, 1 if anything failed, 0 otherwise
+func cleanExitCode(results []*cleanResult, repositoryCount int) int {
+	exitCode := 0
+	if len(results) < repositoryCount {
+		return 130
+	}
+	for _, result := range results {
+		if result.interrupted {
+			return 130
+		}
+		if result.err != nil || len(result.failed) > 0 {
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+// printCleanSummary reports the outcome for each repository, including those skipped after an interrupt
+func printCleanSummary(results []*cleanResult, skipped []string) {
+	fmt.Println("\nSummary:")
+	for _, result := range results {
+		switch {
+		case result.err != nil:
+			fmt.Printf("  %-40s error: %s\n", result.repository, result.err)
+		case result.interrupted:
+			fmt.Printf("  %-40s interrupted: %d of %d release(s) deleted, %d failed, %d not processed\n", result.repository, len(result.deleted), len(result.matched), len(result.failed), len(result.remaining))
+		default:
+			fmt.Printf("  %-40s %d release(s) total, %d matched, %d deleted, %d failed\n", result.repository, result.total, len(result.matched), len(result.deleted), len(result.failed))
+		}
+		printReleaseTags("    Failed:", result.failed)
+		printReleaseTags("    Not processed:", result.remaining)
+	}
+	for _, repository := range skipped {
+		fmt.Printf("  %-40s not processed (interrupted)\n", repository)
+	}
+}
+
+// handleInterrupts returns a context that is cancelled on the first SIGINT/SIGTERM,
+// while a second signal terminates the process immediately
+func handleInterrupts() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			fmt.Println("\nInterrupted, stopping after the in-flight deletion (interrupt again to force quit)..")
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+		<-signals
+		fmt.Println("\nForce quitting, the in-flight deletion may be incomplete")
+		os.Exit(130)
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// printInterruptSummary reports which releases were and weren't cleaned up before an interrupt
+func printInterruptSummary(deleted []*github.RepositoryRelease, failed []*github.RepositoryRelease, remaining []*github.RepositoryRelease) {
+	fmt.Printf("\nCleanup interrupted: %d release(s) deleted, %d failed, %d not processed\n", len(deleted), len(failed), len(remaining))
+	printReleaseTags("Deleted:", deleted)
+	printReleaseTags("Failed:", failed)
+	printReleaseTags("Not processed:", remaining)
+}
+
+// printReleaseTags prints a heading followed by the tag of each release
+func printReleaseTags(heading string, releases []*github.RepositoryRelease) {
+	if len(releases) == 0 {
+		return
+	}
+	indent := heading[:len(heading)-len(strings.TrimLeft(heading, " "))]
+	fmt.Println(heading)
+	for _, release := range releases {
+		fmt.Println(indent+"  -", release.GetTagName())
+	}
 }
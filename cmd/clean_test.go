@@ -1,7 +1,94 @@
 package cmd
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
 
-func TestDummy(t *testing.T) {
+	"github.com/Didstopia/githubby/ghapi/ghapitest"
+	"github.com/google/go-github/v24/github"
+	"github.com/spf13/viper"
+)
 
+func TestCleanDummy(t *testing.T) {
+
+}
+
+func TestReadRepositoryList(t *testing.T) {
+	repositories, err := readRepositoryList(strings.NewReader("# Services\nuser/one\n\n  user/two  \n# user/three\ngit@github.com:user/four.git\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"user/one", "user/two", "git@github.com:user/four.git"}
+	if strings.Join(repositories, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v, got %v", expected, repositories)
+	}
+}
+
+func TestCleanExitCode(t *testing.T) {
+	succeeded := &cleanResult{}
+	failedDeletion := &cleanResult{failed: []*github.RepositoryRelease{{}}}
+	failedRepository := &cleanResult{err: errors.New("failed")}
+	interrupted := &cleanResult{interrupted: true}
+
+	tests := []struct {
+		name            string
+		results         []*cleanResult
+		repositoryCount int
+		expected        int
+	}{
+		{"all succeeded", []*cleanResult{succeeded, succeeded}, 2, 0},
+		{"failed deletion", []*cleanResult{succeeded, failedDeletion}, 2, 1},
+		{"failed repository", []*cleanResult{failedRepository, succeeded}, 2, 1},
+		{"interrupted", []*cleanResult{failedRepository, interrupted}, 2, 130},
+		{"skipped repositories", []*cleanResult{succeeded}, 2, 130},
+	}
+	for _, test := range tests {
+		if exitCode := cleanExitCode(test.results, test.repositoryCount); exitCode != test.expected {
+			t.Errorf("%s: expected exit code %d, got %d", test.name, test.expected, exitCode)
+		}
+	}
+}
+
+func TestCleanRepositoryOffline(t *testing.T) {
+	// Serve the repository from a stand-in GitHub API server, starting from an empty config
+	fake := ghapitest.NewFake()
+	now := time.Now()
+	for index := 0; index < 5; index++ {
+		fake.AddRelease("acme", "app", fmt.Sprintf("v1.0.%d", index), now.Add(-time.Duration(index)*time.Hour))
+	}
+	fake.Fail(ghapitest.OpDeleteRelease, ghapitest.FaultForbidden, 1)
+	server := ghapitest.NewServer(fake)
+	defer server.Close()
+
+	defer func(config *viper.Viper, baseURL string, token string, verbose bool, filterDays int64, filterCount int64, action string) {
+		viperConfig, BaseURL, Token, Verbose = config, baseURL, token, verbose
+		FilterDays, FilterCount, Action = filterDays, filterCount, action
+	}(viperConfig, BaseURL, Token, Verbose, FilterDays, FilterCount, Action)
+	viperConfig = viper.New()
+	viperConfig.SetConfigType("yaml")
+	if err := viperConfig.ReadConfig(bytes.NewBufferString("")); err != nil {
+		t.Fatal(err)
+	}
+	BaseURL, Token, Verbose = server.BaseURL, "token", true
+	FilterDays, FilterCount, Action = -1, 2, actionDelete
+
+	result := cleanRepository(context.Background(), "acme/app")
+	if result.err != nil {
+		t.Fatal(result.err)
+	}
+	if result.total != 5 || len(result.matched) != 3 || len(result.deleted) != 2 || len(result.failed) != 1 {
+		t.Errorf("unexpected result: %d total, %d matched, %d deleted, %d failed", result.total, len(result.matched), len(result.deleted), len(result.failed))
+	}
+
+	// The two newest releases are kept, along with the one that failed to be deleted
+	tags := fake.Tags("acme", "app")
+	if strings.Join(tags, ",") != "v1.0.0,v1.0.1,v1.0.2" {
+		t.Errorf("unexpected remaining tags: %v", tags)
+	}
 }
@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
+	"github.com/joho/godotenv"
 	"github.com/mitchellh/go-homedir"
-	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -14,86 +18,245 @@ import (
 )
 
 const (
-	configFileName = ".githubby"
-	configFileType = "yaml"
+	configFileName    = ".githubby"
+	configFileType    = "yaml"
+	configDirName     = "githubby"
+	xdgConfigFileName = "config.yaml"
+
+	profileEnv = envPrefix + "_PROFILE"
+
+	credentialStoreFileName    = ".githubby-credentials"
+	xdgCredentialStoreFileName = "credentials"
 )
 
 type yamlConfig struct {
-	Verbose     bool   `yaml:"verbose"`
-	DryRun      bool   `yaml:"dry-run"`
-	Token       string `yaml:"token"`
-	Repository  string `yaml:"repository"`
-	FilterDays  int    `yaml:"filter-days"`
-	FilterCount int    `yaml:"filter-count"`
+	Verbose          bool       `yaml:"verbose"`
+	LogFormat        string     `yaml:"log-format"`
+	LogFile          string     `yaml:"log-file"`
+	DryRun           bool       `yaml:"dry-run"`
+	Token            string     `yaml:"token,omitempty"`
+	TokenFile        string     `yaml:"token-file"`
+	GitCredential    bool       `yaml:"git-credential"`
+	Repository       stringList `yaml:"repository"`
+	RepositoryFile   string     `yaml:"repository-file"`
+	Org              string     `yaml:"org"`
+	User             string     `yaml:"user"`
+	Topic            []string   `yaml:"topic"`
+	Name             []string   `yaml:"name"`
+	ExcludeArchived  bool       `yaml:"exclude-archived"`
+	FilterDays       int        `yaml:"filter-days"`
+	FilterCount      int        `yaml:"filter-count"`
+	RepositoryPolicy bool       `yaml:"repository-policy"`
+	KeepTags         []string   `yaml:"keep-tags"`
+	KeepPrereleases  bool       `yaml:"keep-prereleases"`
+	KeepDrafts       bool       `yaml:"keep-drafts"`
+	Action           string     `yaml:"action"`
+	AuditLog         string     `yaml:"audit-log"`
+	WebhookURL       []string   `yaml:"webhook-url"`
+	WebhookFormat    string     `yaml:"webhook-format"`
+	WebhookTemplate  string     `yaml:"webhook-template"`
+	WebhookSecret    string     `yaml:"webhook-secret,omitempty"`
+
+	Defaults *policyConfig  `yaml:"defaults,omitempty"`
+	Policies []policyConfig `yaml:"policies,omitempty"`
+
+	AppID             int64  `yaml:"app-id"`
+	AppPrivateKey     string `yaml:"app-private-key"`
+	AppInstallationID int64  `yaml:"app-installation-id"`
+
+	BaseURL   string `yaml:"base-url"`
+	UploadURL string `yaml:"upload-url"`
+
+	RetryMaxAttempts int    `yaml:"retry-max-attempts"`
+	RetryBackoff     string `yaml:"retry-backoff"`
+	RetryMaxBackoff  string `yaml:"retry-max-backoff"`
+
+	Proxy          string `yaml:"proxy"`
+	CABundle       string `yaml:"ca-bundle"`
+	Insecure       bool   `yaml:"insecure"`
+	ConnectTimeout string `yaml:"connect-timeout"`
+	RequestTimeout string `yaml:"request-timeout"`
+	TraceHTTP      bool   `yaml:"trace-http"`
+
+	DefaultProfile string                 `yaml:"default-profile,omitempty"`
+	Profiles       map[string]*yamlConfig `yaml:"profiles,omitempty"`
+}
+
+// stringList is a list of strings in the config file, also accepting a single string like viper does
+// (eg. the "repository: user/repo" of config files written before multiple repositories were supported)
+type stringList []string
+
+// UnmarshalYAML decodes either a single string (an empty string being an empty list) or a list of strings
+func (list *stringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err == nil {
+		*list = nil
+		if value != "" {
+			*list = stringList{value}
+		}
+		return nil
+	}
+	var values []string
+	if err := unmarshal(&values); err != nil {
+		return err
+	}
+	*list = values
+	return nil
+}
+
+// tokenConfig is the part of a config file checked for exposed tokens
+type tokenConfig struct {
+	Token    string                  `yaml:"token"`
+	Profiles map[string]*tokenConfig `yaml:"profiles"`
 }
 
 // The primary viper object
 var viperConfig *viper.Viper = viper.New()
 
+// The error applying the selected profile, if any
+var profileErr error
+
 func initConfig() {
-	// Make sure the default config file exists
-	if err := writeDefaultConfig(); err != nil {
-		logErrorAndExit(err)
+	// Load the environment files first, as they may select the config file's profile
+	if len(EnvFiles) > 0 {
+		if err := godotenv.Load(EnvFiles...); err != nil {
+			logErrorAndExit(fmt.Errorf("unable to load the environment file: %w", err))
+		}
 	}
 
-	// Search the home directory for a config file
-	homeDir, err := getHomePath()
+	// Find the config file (a missing config file is fine, unless it was explicitly supplied)
+	path, err := findConfigFile()
 	logErrorAndExit(err)
-	viperConfig.AddConfigPath(homeDir)
-
-	// Also search the current directory
-	viperConfig.AddConfigPath(".")
-
-	// Set the config file name (without extension) and type (effectively the extension)
-	viperConfig.SetConfigName(configFileName)
-	viperConfig.SetConfigType(configFileType)
 
 	// Attempt to load the configuration file
-	if err := viperConfig.ReadInConfig(); err != nil {
-		logErrorAndExit(err)
+	if path != "" {
+		viperConfig.SetConfigFile(path)
+		viperConfig.SetConfigType(configFileType)
+		if err := viperConfig.ReadInConfig(); err != nil {
+			logErrorAndExit(fmt.Errorf("unable to read the config file %s: %w", path, err))
+		}
 	}
 
-	// Enable environment variable support
+	// Apply the selected profile on top of the top-level values (reported by the commands, as "config set" may create it)
+	profileErr = applyProfile(viperConfig, selectedProfile(viperConfig))
+
+	// Enable environment variable support, only for prefixed variables (eg. GITHUBBY_FILTER_DAYS for "filter-days")
+	viperConfig.SetEnvPrefix(envPrefix)
+	viperConfig.SetEnvKeyReplacer(envKeyReplacer)
 	viperConfig.AutomaticEnv()
 
-	// Set default log level
-	if viperConfig.GetBool("verbose") {
-		log.SetLevel(logrus.DebugLevel)
-	} else {
-		log.SetLevel(logrus.InfoLevel)
+	// Set up logging as configured
+	logErrorAndExit(configureLogging())
+
+	// Warn about tokens stored in a config file other users can read
+	warnAboutExposedToken(viperConfig.ConfigFileUsed())
+}
+
+// findConfigFile returns the config file to read: the one supplied with --config, or otherwise
+// the first existing one of the XDG config file, the legacy home directory file and the current directory file
+func findConfigFile() (string, error) {
+	if ConfigFile != "" {
+		if _, err := os.Stat(ConfigFile); err != nil {
+			return "", fmt.Errorf("unable to read the config file: %w", err)
+		}
+		return ConfigFile, nil
 	}
+
+	candidates, err := configFileCandidates()
+	if err != nil {
+		return "", err
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", nil
 }
 
-func writeDefaultConfig() error {
-	// Get the config file path
-	path, err := getConfigFilePath()
+// configFileCandidates returns the locations searched for a config file, in order
+func configFileCandidates() ([]string, error) {
+	configDir, err := getConfigDir()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	home, err := getHomePath()
+	if err != nil {
+		return nil, err
 	}
+	return []string{
+		filepath.Join(configDir, xdgConfigFileName),
+		filepath.Join(home, configFileName+"."+configFileType),
+		configFileName + "." + configFileType,
+	}, nil
+}
 
-	// Check if the config file exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		// Create a new config file with the default options
-		if _, err := os.Create(path); err != nil {
-			return err
-		}
-		config := yamlConfig{
-			Verbose:     false,
-			DryRun:      false,
-			Token:       "",
-			Repository:  "",
-			FilterDays:  -1,
-			FilterCount: -1,
-		}
-		data, err := yaml.Marshal(&config)
-		if err != nil {
-			return err
-		}
-		if err := ioutil.WriteFile(path, data, os.ModePerm); err != nil {
-			return err
+// getConfigDir returns the githubby directory below $XDG_CONFIG_HOME (defaulting to ~/.config)
+func getConfigDir() (string, error) {
+	if xdgConfigHome := os.Getenv("XDG_CONFIG_HOME"); xdgConfigHome != "" {
+		return filepath.Join(xdgConfigHome, configDirName), nil
+	}
+	home, err := getHomePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", configDirName), nil
+}
+
+// selectedProfile returns the profile supplied with --profile, GITHUBBY_PROFILE or the config file's "default-profile"
+func selectedProfile(config *viper.Viper) string {
+	if Profile != "" {
+		return Profile
+	}
+	if profile := os.Getenv(profileEnv); profile != "" {
+		return profile
+	}
+	return config.GetString("default-profile")
+}
+
+// applyProfile merges the values of a named profile over the top-level config values
+func applyProfile(config *viper.Viper, profile string) error {
+	if profile == "" {
+		return nil
+	}
+	profileConfig := config.Sub("profiles." + profile)
+	if profileConfig == nil {
+		return errors.New("unknown profile \"" + profile + "\" (define it below 'profiles' in the config file)")
+	}
+	return config.MergeConfigMap(profileConfig.AllSettings())
+}
+
+func warnAboutExposedToken(path string) {
+	if path == "" || runtime.GOOS == "windows" {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm()&0077 == 0 {
+		return
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	config := tokenConfig{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		log.WithField("path", path).WithError(err).Warn("Unable to check the config file for exposed tokens")
+	} else if config.containsToken() {
+		log.WithField("path", path).Warn("The config file contains a token but is readable by other users, restrict it with 'chmod 600' or move the token to the credential store")
+	}
+}
+
+// containsToken checks if the config or any of its profiles holds a token
+func (config *tokenConfig) containsToken() bool {
+	if config.Token != "" {
+		return true
+	}
+	for _, profile := range config.Profiles {
+		if profile != nil && profile.containsToken() {
+			return true
 		}
 	}
-	return nil
+	return false
 }
 
 func getHomePath() (string, error) {
@@ -105,23 +268,52 @@ func getHomePath() (string, error) {
 	return home, nil
 }
 
-func getConfigFilePath() (string, error) {
-	// Construct and return the path to the config file
-	home, err := getHomePath()
-	if err != nil {
-		return "", err
+// The prefix of the environment variables read for config keys
+const envPrefix = "GITHUBBY"
+
+// Maps config keys to environment variable names (eg. "filter-days" to FILTER_DAYS)
+var envKeyReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+// The environment variables explicitly bound to config keys
+var configEnvBindings = make(map[string]string)
+
+// bindEnv binds an environment variable to a config key, remembering it so the value source can be reported
+func bindEnv(key string, env string) {
+	configEnvBindings[key] = env
+	viperConfig.BindEnv(key, env)
+}
+
+// configEnvNames returns the environment variables viper reads a config key from, in the order they're checked
+func configEnvNames(key string) []string {
+	names := []string{envPrefix + "_" + strings.ToUpper(envKeyReplacer.Replace(key))}
+	if env, ok := configEnvBindings[key]; ok && env != names[0] {
+		names = append(names, env)
 	}
-	return filepath.Join(home, configFileName+"."+configFileType), nil
+	return names
 }
 
+// The flags explicitly set on the command line (before any config file values were injected)
+var commandLineFlags = make(map[string]bool)
+
 // Read explicitly set values from viper and override Flags
 // values with the same long-name if they were not explicitly set via cmd line
 func injectViper(cmdViper *viper.Viper, cmd *cobra.Command) {
 	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		// Remember which flags were explicitly set on the command line, as they take precedence over policies
+		if f.Changed {
+			commandLineFlags[f.Name] = true
+		}
 		if !f.Changed {
 			if cmdViper.IsSet(f.Name) {
 				//log.Debug("Injecting ", f.Name, " -> ", cmdViper.GetString(f.Name))
-				cmd.Flags().Set(f.Name, cmdViper.GetString(f.Name))
+				if f.Value.Type() == "stringSlice" {
+					// Lists are set in one go, as setting a slice flag repeatedly appends to it
+					if values := cmdViper.GetStringSlice(f.Name); len(values) > 0 {
+						cmd.Flags().Set(f.Name, strings.Join(values, ","))
+					}
+				} else {
+					cmd.Flags().Set(f.Name, cmdViper.GetString(f.Name))
+				}
 			}
 		}
 	})
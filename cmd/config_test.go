@@ -1,7 +1,167 @@
 package cmd
 
-import "testing"
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
 
-func TestDummy(t *testing.T) {
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
 
+const testProfileConfig = `
+filter-count: 50
+base-url: https://ghe.example.com/api/v3/
+profiles:
+  work:
+    filter-count: 10
+    org: acme
+`
+
+func TestApplyProfile(t *testing.T) {
+	config := viper.New()
+	config.SetConfigType("yaml")
+	if err := config.ReadConfig(bytes.NewBufferString(testProfileConfig)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := applyProfile(config, "work"); err != nil {
+		t.Fatal(err)
+	}
+	if config.GetInt("filter-count") != 10 || config.GetString("org") != "acme" {
+		t.Errorf("expected the profile values to override the top-level values, got %v", config.AllSettings())
+	}
+	if config.GetString("base-url") != "https://ghe.example.com/api/v3/" {
+		t.Errorf("expected the top-level values missing from the profile to be kept, got %v", config.AllSettings())
+	}
+
+	if err := applyProfile(config, "missing"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
+
+func TestFindConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "githubby-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("XDG_CONFIG_HOME", dir)
+
+	// The XDG config file is used when it exists
+	path := filepath.Join(dir, configDirName, xdgConfigFileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte("verbose: true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := findConfigFile(); err != nil || found != path {
+		t.Errorf("expected %s, got %q (%v)", path, found, err)
+	}
+
+	// An explicitly supplied config file must exist
+	defer func() { ConfigFile = "" }()
+	ConfigFile = filepath.Join(dir, "missing.yaml")
+	if _, err := findConfigFile(); err == nil {
+		t.Error("expected an error for a missing config file")
+	}
+}
+
+func TestConfigEnvNames(t *testing.T) {
+	if names := configEnvNames("filter-days"); len(names) != 1 || names[0] != "GITHUBBY_FILTER_DAYS" {
+		t.Errorf("expected GITHUBBY_FILTER_DAYS, got %q", names)
+	}
+
+	// Explicit bindings matching the prefixed name aren't reported twice
+	if names := configEnvNames("base-url"); len(names) != 1 || names[0] != "GITHUBBY_BASE_URL" {
+		t.Errorf("expected GITHUBBY_BASE_URL, got %q", names)
+	}
+}
+
+// The config file written by earlier versions, holding a single repository
+const testLegacyConfig = `verbose: false
+dry-run: false
+token: ghp_legacy
+repository: ""
+filter-days: -1
+filter-count: -1
+`
+
+func TestStringListAcceptsSingleString(t *testing.T) {
+	tests := map[string][]string{
+		`repository: ""`:                    nil,
+		`repository: user/repo`:             {"user/repo"},
+		`repository: [user/repo, user/lib]`: {"user/repo", "user/lib"},
+	}
+	for data, expected := range tests {
+		config := yamlConfig{}
+		if err := yaml.UnmarshalStrict([]byte(data), &config); err != nil {
+			t.Errorf("expected %q to be accepted, got %v", data, err)
+		} else if strings.Join(config.Repository, ",") != strings.Join(expected, ",") {
+			t.Errorf("expected %q from %q, got %q", expected, data, config.Repository)
+		}
+	}
+	if err := yaml.Unmarshal([]byte("repository: {name: user/repo}"), &yamlConfig{}); err == nil {
+		t.Error("expected a mapping to be rejected")
+	}
+}
+
+func TestWarnAboutExposedToken(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions aren't checked on Windows")
+	}
+	defer func(out io.Writer) {
+		log.SetOutput(out)
+	}(log.Out)
+	out := &bytes.Buffer{}
+	log.SetOutput(out)
+
+	dir, err := ioutil.TempDir("", "githubby-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, configFileName+"."+configFileType)
+
+	tests := map[string]string{
+		testLegacyConfig: "contains a token but is readable by other users",
+		"profiles:\n  work:\n    token: ghp_work\n": "contains a token but is readable by other users",
+		"token: [unterminated\n":                    "Unable to check the config file for exposed tokens",
+		"token: \"\"\nrepository: user/repo\n":      "",
+	}
+	for data, expected := range tests {
+		out.Reset()
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, 0644); err != nil {
+			t.Fatal(err)
+		}
+		warnAboutExposedToken(path)
+		if expected == "" && out.Len() > 0 {
+			t.Errorf("expected no warning for %q, got %s", data, out)
+		} else if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q for %q, got %s", expected, data, out)
+		}
+	}
+
+	// Files only the owner can read are fine
+	out.Reset()
+	if err := ioutil.WriteFile(path, []byte(testLegacyConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	warnAboutExposedToken(path)
+	if out.Len() > 0 {
+		t.Errorf("expected no warning for a private config file, got %s", out)
+	}
 }
@@ -0,0 +1,559 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// The config keys holding secrets, which are never displayed
+var secretConfigKeys = map[string]bool{
+	"token":          true,
+	"proxy":          true,
+	"webhook-url":    true,
+	"webhook-secret": true,
+}
+
+// The config keys that can't be changed with "config set" (too complex, or not meant to be stored in the file)
+var unsettableConfigKeys = map[string]bool{
+	"token":    true,
+	"defaults": true,
+	"policies": true,
+	"profiles": true,
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the config file",
+	Long:  `Create, inspect, validate and change the githubby config file`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Don't require a token or repository for managing the config file, and allow "config set" to create profiles
+		if cmd != configSetCmd {
+			logErrorAndExit(profileErr)
+		}
+	},
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a new config file",
+	Long: `Create a new config file by answering a few questions (press enter to accept the default).
+The file is written to --config, or to $XDG_CONFIG_HOME/githubby/config.yaml by default.
+Tokens are never written to the config file, store them with 'githubby auth login' instead.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := configWritePath(false)
+		if err != nil {
+			logErrorAndExit(err)
+		}
+		if _, err := os.Stat(path); err == nil && !ConfigForce {
+			logErrorAndExit(errors.New("the config file " + path + " already exists (use --force to overwrite it)"))
+		}
+
+		data, err := promptConfig(bufio.NewReader(os.Stdin), os.Stderr)
+		if err != nil {
+			logErrorAndExit(err)
+		}
+		if err := writeConfigFile(path, data); err != nil {
+			logErrorAndExit(err)
+		}
+		fmt.Println("Created config file", path)
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the effective configuration",
+	Long: `Show the effective value of each config key along with where it came from
+(flag, environment variable, config file, profile or default), with secrets redacted`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if path := viperConfig.ConfigFileUsed(); path != "" {
+			fmt.Println("Config file:", path)
+		} else {
+			fmt.Println("Config file: none")
+		}
+		if profile := selectedProfile(viperConfig); profile != "" {
+			fmt.Println("Profile:    ", profile)
+		}
+		fmt.Println()
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "KEY\tVALUE\tSOURCE")
+		for _, key := range configKeys() {
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", key, formatConfigValue(key, viperConfig.Get(key)), configValueSource(cmd, key))
+		}
+		writer.Flush()
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Validate a config file",
+	Long:  `Validate a config file (the one currently in use by default), reporting unknown keys and invalid values`,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := viperConfig.ConfigFileUsed()
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if path == "" {
+			logErrorAndExit(errors.New("no config file found"))
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			logErrorAndExit(err)
+		}
+		if problems := validateConfig(data); len(problems) > 0 {
+			fmt.Println("The config file", path, "is invalid:")
+			for _, problem := range problems {
+				fmt.Println("  " + problem)
+			}
+			os.Exit(1)
+		}
+		fmt.Println("The config file", path, "is valid")
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Show the effective value of a config key",
+	Long:  `Show the effective value of a config key, after applying flags, environment variables and the selected profile`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		key := strings.ToLower(args[0])
+		if _, known := configKeyType(key); !known && !viperConfig.IsSet(key) {
+			logErrorAndExit(errors.New("unknown config key \"" + key + "\""))
+		}
+		fmt.Println(formatConfigValue(key, viperConfig.Get(key)))
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a value in the config file",
+	Long: `Change a value in the config file (or in the selected profile with --profile).
+Lists are separated by commas (eg. 'githubby config set keep-tags "v1.*,v2.*"').
+The order of the keys and any comments are kept, but the file is reformatted (eg. blank lines are removed).`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := configWritePath(true)
+		if err != nil {
+			logErrorAndExit(err)
+		}
+
+		// Read the existing config file, starting from scratch if there is none
+		data, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			logErrorAndExit(err)
+		}
+		data, err = setConfigValue(data, Profile, strings.ToLower(args[0]), args[1])
+		if err != nil {
+			logErrorAndExit(err)
+		}
+
+		// Never write a config file that wouldn't load afterwards
+		if problems := validateConfig(data); len(problems) > 0 {
+			logErrorAndExit(errors.New("the change would make the config file invalid: " + strings.Join(problems, ", ")))
+		}
+		if err := writeConfigFile(path, data); err != nil {
+			logErrorAndExit(err)
+		}
+		fmt.Println("Set", strings.ToLower(args[0]), "in", path)
+	},
+}
+
+// configWritePath returns the config file to write to: the one supplied with --config, optionally the one
+// currently in use, or otherwise the XDG config file
+func configWritePath(preferExisting bool) (string, error) {
+	if ConfigFile != "" {
+		return ConfigFile, nil
+	}
+	if path := viperConfig.ConfigFileUsed(); preferExisting && path != "" {
+		return path, nil
+	}
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, xdgConfigFileName), nil
+}
+
+// writeConfigFile writes a config file only its owner can access, creating its directory if needed
+func writeConfigFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+
+	// WriteFile keeps the permissions of an existing file, so enforce them explicitly
+	return os.Chmod(path, 0600)
+}
+
+// configPrompt is a single question asked by "config init"
+type configPrompt struct {
+	key          string
+	question     string
+	defaultValue string
+}
+
+// The questions asked by "config init", in order
+var configPrompts = []configPrompt{
+	{"base-url", "GitHub Enterprise Server API URL (leave empty for github.com)", ""},
+	{"filter-days", "Clean up releases older than this many days (-1 to disable)", "-1"},
+	{"filter-count", "Clean up all but this many of the newest releases (-1 to disable)", "-1"},
+	{"keep-prereleases", "Never clean up prereleases (true/false)", "false"},
+	{"keep-drafts", "Never clean up draft releases (true/false)", "false"},
+	{"action", "Action for matching releases (" + actionDelete + "/" + actionDeleteRelease + ")", actionDelete},
+}
+
+// promptConfig asks the config init questions, returning the resulting config file (the defaults are used at EOF)
+func promptConfig(reader *bufio.Reader, output io.Writer) ([]byte, error) {
+	data := []byte{}
+	for _, prompt := range configPrompts {
+		for {
+			fmt.Fprintf(output, "%s [%s]: ", prompt.question, prompt.defaultValue)
+			line, err := reader.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			value := strings.TrimSpace(line)
+			if value == "" {
+				value = prompt.defaultValue
+			}
+			if err == io.EOF && line == "" {
+				fmt.Fprintln(output)
+			}
+
+			// Ask again until the answer is valid
+			updated, setErr := setConfigValue(data, "", prompt.key, value)
+			if setErr == nil {
+				if problems := validateConfig(updated); len(problems) > 0 {
+					setErr = errors.New(strings.Join(problems, ", "))
+				}
+			}
+			if setErr == nil {
+				data = updated
+				break
+			}
+			if err == io.EOF {
+				return nil, setErr
+			}
+			fmt.Fprintln(output, "Invalid value:", setErr)
+		}
+	}
+	return data, nil
+}
+
+// configKeys returns the known config keys, excluding the individual profile values
+func configKeys() []string {
+	keys := make([]string, 0)
+	for _, key := range viperConfig.AllKeys() {
+		if !strings.HasPrefix(key, "profiles.") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// configValueSource describes where the effective value of a config key came from, following viper's precedence
+func configValueSource(cmd *cobra.Command, key string) string {
+	if flag := cmd.Flags().Lookup(key); flag != nil && flag.Changed {
+		return "flag --" + key
+	}
+	for _, env := range configEnvNames(key) {
+		if os.Getenv(env) != "" {
+			return "env " + env
+		}
+	}
+
+	// Nested keys (eg. "defaults.filter-days") only ever come from the config file
+	topLevelKey := strings.SplitN(key, ".", 2)[0]
+	if viperConfig.InConfig(topLevelKey) {
+		if profile := selectedProfile(viperConfig); profile != "" {
+			if profileConfig := viperConfig.Sub("profiles." + profile); profileConfig != nil && profileConfig.InConfig(topLevelKey) {
+				return "profile " + profile
+			}
+		}
+		return "file"
+	}
+	return "default"
+}
+
+// formatConfigValue formats a config value for display, redacting secrets
+func formatConfigValue(key string, value interface{}) string {
+	if secretConfigKeys[key] {
+		if fmt.Sprint(value) == "" {
+			return ""
+		}
+		return "<redacted>"
+	}
+	switch typedValue := value.(type) {
+	case []string:
+		return strings.Join(typedValue, ",")
+	case []interface{}:
+		values := make([]string, 0, len(typedValue))
+		for _, item := range typedValue {
+			values = append(values, fmt.Sprint(item))
+		}
+		return strings.Join(values, ",")
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// configKeyType returns the type of a top-level config key, based on the yaml tags of the config file schema
+func configKeyType(key string) (reflect.Type, bool) {
+	configType := reflect.TypeOf(yamlConfig{})
+	for index := 0; index < configType.NumField(); index++ {
+		field := configType.Field(index)
+		if strings.Split(field.Tag.Get("yaml"), ",")[0] == key {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+// parseConfigValue converts a command line value to the type of a config key
+func parseConfigValue(key string, value string) (interface{}, error) {
+	keyType, known := configKeyType(key)
+	if !known {
+		return nil, errors.New("unknown config key \"" + key + "\"")
+	}
+	if unsettableConfigKeys[key] {
+		if key == "token" {
+			return nil, errors.New("tokens are never stored in the config file, use 'githubby auth login' instead")
+		}
+		return nil, errors.New("\"" + key + "\" can't be set from the command line, edit the config file instead")
+	}
+
+	switch keyType.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Int, reflect.Int64:
+		return strconv.ParseInt(value, 10, 64)
+	case reflect.Slice:
+		values := make([]string, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		return values, nil
+	}
+	return value, nil
+}
+
+// setConfigValue sets a top-level key (or a key of the named profile) in a config file, keeping the order of its keys
+// and any comments
+func setConfigValue(data []byte, profile string, key string, value string) ([]byte, error) {
+	parsedValue, err := parseConfigValue(key, value)
+	if err != nil {
+		return nil, err
+	}
+
+	document := yamlv3.Node{}
+	if err := yamlv3.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if document.Kind == 0 {
+		// An empty file has no document yet
+		document = yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{{Kind: yamlv3.MappingNode}}}
+	}
+	config := document.Content[0]
+	if config.Kind != yamlv3.MappingNode {
+		return nil, errors.New("the config file must contain a YAML mapping of keys to values")
+	}
+
+	valueNode := &yamlv3.Node{}
+	if err := valueNode.Encode(parsedValue); err != nil {
+		return nil, err
+	}
+	if profile != "" {
+		if config, err = mappingNodeValue(config, "profiles"); err == nil {
+			config, err = mappingNodeValue(config, profile)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	setMappingNodeValue(config, key, valueNode)
+
+	var output bytes.Buffer
+	encoder := yamlv3.NewEncoder(&output)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+
+// mappingNodeValue returns the mapping stored below a key of a YAML mapping, adding it if it's missing or empty
+func mappingNodeValue(mapping *yamlv3.Node, key string) (*yamlv3.Node, error) {
+	for index := 0; index+1 < len(mapping.Content); index += 2 {
+		if mapping.Content[index].Value != key {
+			continue
+		}
+		value := mapping.Content[index+1]
+		if value.Kind == yamlv3.ScalarNode && value.Tag == "!!null" {
+			value.Kind, value.Tag, value.Value = yamlv3.MappingNode, "", ""
+		}
+		if value.Kind != yamlv3.MappingNode {
+			return nil, errors.New("\"" + key + "\" must be a YAML mapping of keys to values")
+		}
+		return value, nil
+	}
+	value := &yamlv3.Node{Kind: yamlv3.MappingNode}
+	setMappingNodeValue(mapping, key, value)
+	return value, nil
+}
+
+// setMappingNodeValue replaces the value of a key in a YAML mapping (keeping its comments), appending the key if it's missing
+func setMappingNodeValue(mapping *yamlv3.Node, key string, value *yamlv3.Node) {
+	for index := 0; index+1 < len(mapping.Content); index += 2 {
+		if mapping.Content[index].Value == key {
+			previous := mapping.Content[index+1]
+			value.HeadComment, value.LineComment, value.FootComment = previous.HeadComment, previous.LineComment, previous.FootComment
+			mapping.Content[index+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// Matches the Go type names in yaml errors, which mean nothing to users (eg. " in type cmd.yamlConfig")
+var yamlTypeNamePattern = regexp.MustCompile(` in type \S+`)
+
+// validateConfig checks a config file against the schema and the supported values, returning any problems
+// (schema problems include the line number)
+func validateConfig(data []byte) []string {
+	config := yamlConfig{}
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		typeError, ok := err.(*yaml.TypeError)
+		if !ok {
+			return []string{strings.TrimPrefix(err.Error(), "yaml: ")}
+		}
+		problems := make([]string, 0, len(typeError.Errors))
+		for _, problem := range typeError.Errors {
+			problems = append(problems, yamlTypeNamePattern.ReplaceAllString(problem, ""))
+		}
+		return problems
+	}
+
+	problems := config.validate("")
+	if config.DefaultProfile != "" && config.Profiles[config.DefaultProfile] == nil {
+		problems = append(problems, "default-profile: unknown profile \""+config.DefaultProfile+"\"")
+	}
+	profiles := make([]string, 0, len(config.Profiles))
+	for name := range config.Profiles {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+	for _, name := range profiles {
+		if profileConfig := config.Profiles[name]; profileConfig != nil {
+			if len(profileConfig.Profiles) > 0 || profileConfig.DefaultProfile != "" {
+				problems = append(problems, "profiles."+name+": profiles can't be nested")
+			}
+			problems = append(problems, profileConfig.validate("profiles."+name+".")...)
+		}
+	}
+	return problems
+}
+
+// validate checks the values of the config (or of a profile), prefixing the reported keys
+func (config *yamlConfig) validate(prefix string) []string {
+	problems := make([]string, 0)
+	if config.Action != "" {
+		problems = append(problems, validateAction(prefix+"action", config.Action)...)
+	}
+	problems = append(problems, validateTagPatterns(prefix+"keep-tags", config.KeepTags)...)
+	if _, err := logFormatter(config.LogFormat); err != nil {
+		problems = append(problems, prefix+"log-format: "+err.Error())
+	}
+	if _, ok := webhookTemplates[config.WebhookFormat]; config.WebhookFormat != "" && config.WebhookFormat != webhookFormatTemplate && !ok {
+		problems = append(problems, prefix+"webhook-format: unsupported webhook format \""+config.WebhookFormat+"\"")
+	}
+	durations := map[string]string{
+		"retry-backoff":     config.RetryBackoff,
+		"retry-max-backoff": config.RetryMaxBackoff,
+		"connect-timeout":   config.ConnectTimeout,
+		"request-timeout":   config.RequestTimeout,
+	}
+	for key, value := range durations {
+		if _, err := time.ParseDuration(value); value != "" && err != nil {
+			problems = append(problems, prefix+key+": invalid duration \""+value+"\" (eg. 1s or 500ms)")
+		}
+	}
+	if config.Defaults != nil {
+		problems = append(problems, config.Defaults.validate(prefix+"defaults.")...)
+		if len(config.Defaults.Repositories) > 0 {
+			problems = append(problems, prefix+"defaults.repositories: not supported in the defaults, they apply to all repositories")
+		}
+	}
+	for index := range config.Policies {
+		entryPrefix := prefix + "policies[" + strconv.Itoa(index) + "]."
+		problems = append(problems, config.Policies[index].validate(entryPrefix)...)
+		if len(config.Policies[index].Repositories) == 0 {
+			problems = append(problems, entryPrefix+"repositories: missing the repositories the policy applies to")
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// validate checks the values of a policy entry, prefixing the reported keys
+func (config *policyConfig) validate(prefix string) []string {
+	problems := make([]string, 0)
+	if config.Action != "" {
+		problems = append(problems, validateAction(prefix+"action", config.Action)...)
+	}
+	problems = append(problems, validateTagPatterns(prefix+"keep-tags", config.KeepTags)...)
+	for _, pattern := range config.Repositories {
+		if _, err := path.Match(pattern, ""); err != nil {
+			problems = append(problems, prefix+"repositories: invalid pattern \""+pattern+"\"")
+		}
+	}
+	return problems
+}
+
+// validateAction checks that an action is supported
+func validateAction(key string, action string) []string {
+	if action != actionDelete && action != actionDeleteRelease {
+		return []string{key + ": unsupported action \"" + action + "\" (use \"" + actionDelete + "\" or \"" + actionDeleteRelease + "\")"}
+	}
+	return nil
+}
+
+// validateTagPatterns checks that tag patterns are valid glob patterns
+func validateTagPatterns(key string, patterns []string) []string {
+	problems := make([]string, 0)
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			problems = append(problems, key+": invalid tag pattern \""+pattern+"\"")
+		}
+	}
+	return problems
+}
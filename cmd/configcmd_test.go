@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bufio"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	problems := validateConfig([]byte(`
+filter-days: 30
+unknown-key: true
+filter-count: many
+action: archive
+profiles:
+  work:
+    retry-backoff: soon
+`))
+	expected := []string{
+		"line 3: field unknown-key not found",
+		"line 4: cannot unmarshal !!str `many` into int",
+	}
+	if strings.Join(problems, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected schema problems %q, got %q", expected, problems)
+	}
+
+	// Values are only checked once the schema is valid
+	problems = validateConfig([]byte(`
+action: archive
+default-profile: home
+profiles:
+  work:
+    retry-backoff: soon
+`))
+	expected = []string{
+		"action: unsupported action \"archive\" (use \"delete\" or \"delete-release\")",
+		"default-profile: unknown profile \"home\"",
+		"profiles.work.retry-backoff: invalid duration \"soon\" (eg. 1s or 500ms)",
+	}
+	if strings.Join(problems, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected value problems %q, got %q", expected, problems)
+	}
+
+	if problems := validateConfig([]byte("filter-days: 30\n")); len(problems) > 0 {
+		t.Errorf("expected a valid config, got %q", problems)
+	}
+
+	// Config files written by earlier versions hold a single repository
+	for _, data := range []string{testLegacyConfig, "repository: user/repo\n"} {
+		if problems := validateConfig([]byte(data)); len(problems) > 0 {
+			t.Errorf("expected %q to be valid, got %q", data, problems)
+		}
+	}
+}
+
+func TestSetConfigValueLegacyConfig(t *testing.T) {
+	data, err := setConfigValue([]byte("repository: user/repo\nfilter-days: -1\n"), "", "filter-days", "30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := validateConfig(data); len(problems) > 0 {
+		t.Errorf("expected the changed config to be valid, got %q", problems)
+	}
+	if expected := "repository: user/repo\nfilter-days: 30\n"; string(data) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, data)
+	}
+
+	// The single repository can be replaced by a list
+	if data, err = setConfigValue(data, "", "repository", "user/repo,user/lib"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "repository:\n  - user/repo\n  - user/lib\nfilter-days: 30\n"; string(data) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, data)
+	}
+	if problems := validateConfig(data); len(problems) > 0 {
+		t.Errorf("expected the changed config to be valid, got %q", problems)
+	}
+}
+
+func TestSetConfigValue(t *testing.T) {
+	data, err := setConfigValue([]byte("verbose: true\nfilter-days: 30\n"), "", "filter-days", "90")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = setConfigValue(data, "work", "keep-tags", "v1.*, v2.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "verbose: true\nfilter-days: 90\nprofiles:\n  work:\n    keep-tags:\n      - v1.*\n      - v2.*\n"
+	if string(data) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, data)
+	}
+
+	// Comments are kept
+	data, err = setConfigValue([]byte("# Nightly cleanup\nfilter-days: 30 # a month\n# Team profiles\nprofiles:\n"), "ops", "filter-count", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err = setConfigValue(data, "", "filter-days", "60"); err != nil {
+		t.Fatal(err)
+	}
+	expected = "# Nightly cleanup\nfilter-days: 60 # a month\n# Team profiles\nprofiles:\n  ops:\n    filter-count: 10\n"
+	if string(data) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, data)
+	}
+
+	for _, key := range []string{"token", "policies", "unknown"} {
+		if _, err := setConfigValue(data, "", key, "value"); err == nil {
+			t.Errorf("expected setting %q to fail", key)
+		}
+	}
+	if _, err := setConfigValue(data, "", "dry-run", "maybe"); err == nil {
+		t.Error("expected an invalid boolean to fail")
+	}
+}
+
+func TestPromptConfig(t *testing.T) {
+	// Invalid answers are asked again, and the defaults are used for the rest
+	input := "\n30\n\nmaybe\ntrue\n"
+	data, err := promptConfig(bufio.NewReader(strings.NewReader(input)), ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "base-url: \"\"\nfilter-days: 30\nfilter-count: -1\nkeep-prereleases: true\nkeep-drafts: false\naction: delete\n"
+	if string(data) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+func TestFormatConfigValue(t *testing.T) {
+	if value := formatConfigValue("token", "secret"); value != "<redacted>" {
+		t.Errorf("expected the token to be redacted, got %q", value)
+	}
+	if value := formatConfigValue("keep-tags", []interface{}{"v1.*", "v2.*"}); value != "v1.*,v2.*" {
+		t.Errorf("expected a comma separated list, got %q", value)
+	}
+}
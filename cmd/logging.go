@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The supported log formats
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// The file logs are written to instead of stderr (nil when logging to stderr)
+var logFile *os.File
+
+// configureLogging applies the log level, format and destination from the config
+func configureLogging() error {
+	if viperConfig.GetBool("verbose") {
+		log.SetLevel(logrus.DebugLevel)
+	} else {
+		log.SetLevel(logrus.InfoLevel)
+	}
+
+	formatter, err := logFormatter(viperConfig.GetString("log-format"))
+	if err != nil {
+		return err
+	}
+	log.SetFormatter(formatter)
+
+	// Append to the log file, keeping user-facing output on stdout and stderr
+	if path := viperConfig.GetString("log-file"); path != "" {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("unable to open the log file: %w", err)
+		}
+		logFile = file
+		log.SetOutput(file)
+	}
+	return nil
+}
+
+// logFormatter returns the formatter for a log format
+func logFormatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case "", logFormatText:
+		return &logrus.TextFormatter{}, nil
+	case logFormatJSON:
+		return &logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano}, nil
+	}
+	return nil, fmt.Errorf("unsupported log format %q (use %q or %q)", format, logFormatText, logFormatJSON)
+}
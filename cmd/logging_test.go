@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogFormatter(t *testing.T) {
+	if formatter, err := logFormatter(""); err != nil {
+		t.Error(err)
+	} else if _, ok := formatter.(*logrus.TextFormatter); !ok {
+		t.Errorf("expected the text formatter by default, got %T", formatter)
+	}
+	if formatter, err := logFormatter("json"); err != nil {
+		t.Error(err)
+	} else if _, ok := formatter.(*logrus.JSONFormatter); !ok {
+		t.Errorf("expected the JSON formatter, got %T", formatter)
+	}
+	if _, err := logFormatter("xml"); err == nil {
+		t.Error("expected an unsupported log format to be rejected")
+	}
+}
+
+func TestConfigureLoggingWritesJSONLinesToFile(t *testing.T) {
+	defer func(out io.Writer, formatter logrus.Formatter, level logrus.Level) {
+		log.SetOutput(out)
+		log.SetFormatter(formatter)
+		log.SetLevel(level)
+		logFile = nil
+	}(log.Out, log.Formatter, log.Level)
+
+	dir, err := ioutil.TempDir("", "githubby-logging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "githubby.log")
+	viperConfig.SetConfigType("yaml")
+	if err := viperConfig.ReadConfig(bytes.NewBufferString("log-format: json\nlog-file: " + path + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	defer viperConfig.ReadConfig(bytes.NewBufferString(""))
+
+	if err := configureLogging(); err != nil {
+		t.Fatal(err)
+	}
+	defer logFile.Close()
+	log.WithFields(logrus.Fields{"repo": "acme/app", "release_id": 42}).Info("Cleaned up release")
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := make(map[string]interface{})
+	if err := json.Unmarshal(content, &entry); err != nil {
+		t.Fatalf("expected a JSON line, got %q: %v", content, err)
+	}
+	if entry["msg"] != "Cleaned up release" || entry["repo"] != "acme/app" || entry["release_id"] != float64(42) || entry["level"] != "info" {
+		t.Errorf("unexpected log entry %v", entry)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected the log file to only be readable by its owner, got %v (%v)", info.Mode(), err)
+	}
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Didstopia/githubby/cleanup"
+	"github.com/Didstopia/githubby/ghapi"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// Supported cleanup actions
+const (
+	actionDelete        = cleanup.ActionDelete
+	actionDeleteRelease = cleanup.ActionDeleteRelease
+)
+
+// The flag and config value that turns a filter off
+const filterDisabled = -1
+
+// policyFilter converts a flag or config filter value to a policy filter (nil when turned off)
+func policyFilter(value int64) *int64 {
+	if value == filterDisabled {
+		return nil
+	}
+	return cleanup.Filter(value)
+}
+
+// policyConfig is a retention policy entry in the config file, where unset fields are inherited
+type policyConfig struct {
+	Repositories    []string `mapstructure:"repositories" yaml:"repositories,omitempty"`
+	FilterDays      *int64   `mapstructure:"filter-days" yaml:"filter-days,omitempty"`
+	FilterCount     *int64   `mapstructure:"filter-count" yaml:"filter-count,omitempty"`
+	KeepTags        []string `mapstructure:"keep-tags" yaml:"keep-tags,omitempty"`
+	KeepPrereleases *bool    `mapstructure:"keep-prereleases" yaml:"keep-prereleases,omitempty"`
+	KeepDrafts      *bool    `mapstructure:"keep-drafts" yaml:"keep-drafts,omitempty"`
+	Action          string   `mapstructure:"action" yaml:"action,omitempty"`
+}
+
+// applyTo overrides the fields of the policy that are set in the config entry
+func (config *policyConfig) applyTo(effective *cleanup.Policy) {
+	if config.FilterDays != nil {
+		effective.FilterDays = policyFilter(*config.FilterDays)
+	}
+	if config.FilterCount != nil {
+		effective.FilterCount = policyFilter(*config.FilterCount)
+	}
+	if config.KeepTags != nil {
+		effective.KeepTags = config.KeepTags
+	}
+	if config.KeepPrereleases != nil {
+		effective.KeepPrereleases = *config.KeepPrereleases
+	}
+	if config.KeepDrafts != nil {
+		effective.KeepDrafts = *config.KeepDrafts
+	}
+	if config.Action != "" {
+		effective.Action = config.Action
+	}
+}
+
+// matches checks if the repository (owner/repo) matches any of the entry's patterns (eg. acme/svc-*)
+func (config *policyConfig) matches(repository string) bool {
+	for _, pattern := range config.Repositories {
+		if matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(repository)); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// loadPolicyConfigs reads the "defaults" and "policies" sections of the config file
+func loadPolicyConfigs() (*policyConfig, []policyConfig, error) {
+	defaults := &policyConfig{}
+	if err := viperConfig.UnmarshalKey("defaults", defaults); err != nil {
+		return nil, nil, fmt.Errorf("invalid 'defaults' in config file: %w", err)
+	}
+	policies := make([]policyConfig, 0)
+	if err := viperConfig.UnmarshalKey("policies", &policies); err != nil {
+		return nil, nil, fmt.Errorf("invalid 'policies' in config file: %w", err)
+	}
+	return defaults, policies, nil
+}
+
+// resolvePolicy returns the effective policy for a repository (owner/repo), where each level overrides the previous one:
+// the top-level config values, the config defaults, the organization policy, the first matching config policy,
+// the repository policy and finally any command line flags (the organization and repository policies are optional)
+func resolvePolicy(repository string, orgPolicy *policyConfig, repoPolicy *policyConfig) (cleanup.Policy, error) {
+	// Start with the flag values, which also hold the top-level config values
+	effective := cleanup.Policy{
+		FilterDays:      policyFilter(FilterDays),
+		FilterCount:     policyFilter(FilterCount),
+		KeepTags:        KeepTags,
+		KeepPrereleases: KeepPrereleases,
+		KeepDrafts:      KeepDrafts,
+		Action:          Action,
+	}
+
+	// Apply the defaults and the first matching policy from the config file, along with the remote policies
+	defaults, policies, err := loadPolicyConfigs()
+	if err != nil {
+		return effective, err
+	}
+	defaults.applyTo(&effective)
+	if orgPolicy != nil {
+		orgPolicy.applyTo(&effective)
+	}
+	for index := range policies {
+		if policies[index].matches(repository) {
+			policies[index].applyTo(&effective)
+			break
+		}
+	}
+	if repoPolicy != nil {
+		repoPolicy.applyTo(&effective)
+	}
+
+	// Command line flags always win
+	if commandLineFlags["filter-days"] {
+		effective.FilterDays = policyFilter(FilterDays)
+	}
+	if commandLineFlags["filter-count"] {
+		effective.FilterCount = policyFilter(FilterCount)
+	}
+	if commandLineFlags["keep-tags"] {
+		effective.KeepTags = KeepTags
+	}
+	if commandLineFlags["keep-prereleases"] {
+		effective.KeepPrereleases = KeepPrereleases
+	}
+	if commandLineFlags["keep-drafts"] {
+		effective.KeepDrafts = KeepDrafts
+	}
+	if commandLineFlags["action"] {
+		effective.Action = Action
+	}
+
+	if err := effective.Validate(); err == cleanup.ErrMissingFilter {
+		return effective, errors.New("missing at least one filter (set 'filter-days' or 'filter-count' with flags or in the config file)")
+	} else if err != nil {
+		return effective, err
+	}
+	return effective, nil
+}
+
+// policyRepositories returns the repositories listed literally (without patterns) in the config policies
+func policyRepositories() ([]string, error) {
+	_, policies, err := loadPolicyConfigs()
+	if err != nil {
+		return nil, err
+	}
+	repositories := make([]string, 0)
+	for _, entry := range policies {
+		for _, repository := range entry.Repositories {
+			if !strings.ContainsAny(repository, "*?[") {
+				repositories = append(repositories, repository)
+			}
+		}
+	}
+	return repositories, nil
+}
+
+// The location of the policy file in a repository, and in the organization's ".github" repository
+const (
+	repositoryPolicyPath = ".github/githubby.yml"
+	orgPolicyRepository  = ".github"
+	orgPolicyPath        = "githubby.yml"
+)
+
+// The organization policies fetched so far, by owner (nil if the owner has none)
+var orgPolicyCache = make(map[string]*policyConfig)
+
+// fetchRemotePolicies fetches the policy file of the repository and the organization defaults from the
+// owner's ".github" repository, returning nil for those that don't exist
+func fetchRemotePolicies(ctx context.Context, client ghapi.RepositoryService, owner string, repo string) (*policyConfig, *policyConfig, error) {
+	orgPolicy, cached := orgPolicyCache[strings.ToLower(owner)]
+	if !cached {
+		var err error
+		orgPolicy, err = fetchPolicy(ctx, client, owner, orgPolicyRepository, orgPolicyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		orgPolicyCache[strings.ToLower(owner)] = orgPolicy
+	}
+
+	repoPolicy, err := fetchPolicy(ctx, client, owner, repo, repositoryPolicyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return orgPolicy, repoPolicy, nil
+}
+
+// fetchPolicy fetches and parses a policy file from the default branch of a repository, returning nil if it doesn't exist
+func fetchPolicy(ctx context.Context, client ghapi.RepositoryService, owner string, repo string, path string) (*policyConfig, error) {
+	content, found, err := client.GetFileContents(ctx, owner, repo, path)
+	if err != nil || !found {
+		return nil, err
+	}
+	remotePolicy, err := parsePolicy(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy file %s in %s/%s: %w", path, owner, repo, err)
+	}
+	log.WithFields(logrus.Fields{"repo": owner + "/" + repo, "path": path}).Debug("Using policy file")
+	return remotePolicy, nil
+}
+
+// parsePolicy parses a policy file, rejecting unknown keys
+func parsePolicy(content []byte) (*policyConfig, error) {
+	remotePolicy := &policyConfig{}
+	if err := yaml.UnmarshalStrict(content, remotePolicy); err != nil {
+		return nil, err
+	}
+	if len(remotePolicy.Repositories) > 0 {
+		return nil, errors.New("'repositories' is not supported in a policy file, it applies to its own repository")
+	}
+	return remotePolicy, nil
+}
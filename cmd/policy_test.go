@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+const testPolicyConfig = `
+filter-count: 50
+defaults:
+  filter-days: 90
+  keep-prereleases: true
+policies:
+  - repositories: ["acme/svc-*"]
+    filter-count: 20
+    keep-tags: ["v1.*"]
+  - repositories: ["acme/web", "acme/svc-legacy"]
+    action: delete-release
+`
+
+// loadTestPolicyConfig loads the test config into a fresh viper instance, returning a function restoring the previous config
+func loadTestPolicyConfig(t *testing.T) func() {
+	restore := func(config *viper.Viper, flags map[string]bool, filterDays int64, filterCount int64, action string) func() {
+		return func() {
+			viperConfig, commandLineFlags = config, flags
+			FilterDays, FilterCount, Action = filterDays, filterCount, action
+		}
+	}(viperConfig, commandLineFlags, FilterDays, FilterCount, Action)
+
+	viperConfig, commandLineFlags = viper.New(), make(map[string]bool)
+	viperConfig.SetConfigType("yaml")
+	if err := viperConfig.ReadConfig(bytes.NewBufferString(testPolicyConfig)); err != nil {
+		restore()
+		t.Fatal(err)
+	}
+	FilterDays, FilterCount, Action = -1, 50, actionDelete
+	return restore
+}
+
+func TestResolvePolicyPrecedence(t *testing.T) {
+	defer loadTestPolicyConfig(t)()
+
+	// The defaults override the top-level values
+	web, err := resolvePolicy("acme/web", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *web.FilterDays != 90 || *web.FilterCount != 50 || !web.KeepPrereleases || web.Action != actionDeleteRelease {
+		t.Errorf("unexpected policy for acme/web: %+v", web)
+	}
+
+	// Only the first matching policy applies
+	legacy, err := resolvePolicy("ACME/svc-legacy", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *legacy.FilterCount != 20 || len(legacy.KeepTags) != 1 || legacy.Action != actionDelete {
+		t.Errorf("unexpected policy for acme/svc-legacy: %+v", legacy)
+	}
+
+	// Command line flags override everything
+	commandLineFlags["filter-count"] = true
+	FilterCount = 5
+	overridden, err := resolvePolicy("acme/svc-api", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *overridden.FilterCount != 5 || *overridden.FilterDays != 90 {
+		t.Errorf("unexpected policy for acme/svc-api: %+v", overridden)
+	}
+}
+
+func TestResolvePolicyWithRemotePolicies(t *testing.T) {
+	defer loadTestPolicyConfig(t)()
+
+	orgPolicy, err := parsePolicy([]byte("filter-days: 30\nfilter-count: 100\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoPolicy, err := parsePolicy([]byte("keep-tags: ['stable-*']\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The organization policy overrides the config defaults, but not the matching config policy,
+	// while the repository policy overrides both
+	effective, err := resolvePolicy("acme/svc-api", orgPolicy, repoPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *effective.FilterDays != 30 || *effective.FilterCount != 20 || len(effective.KeepTags) != 1 || effective.KeepTags[0] != "stable-*" {
+		t.Errorf("unexpected policy for acme/svc-api: %+v", effective)
+	}
+}
+
+func TestResolvePolicyTurnsFiltersOff(t *testing.T) {
+	defer loadTestPolicyConfig(t)()
+
+	// A repository policy can turn off an inherited filter
+	repoPolicy, err := parsePolicy([]byte("filter-days: -1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	effective, err := resolvePolicy("acme/web", nil, repoPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if effective.FilterDays != nil || effective.FilterCount == nil || *effective.FilterCount != 50 {
+		t.Errorf("expected only the count filter to remain, got %+v", effective)
+	}
+
+	// Turning off every filter is refused
+	commandLineFlags["filter-count"] = true
+	FilterCount = -1
+	if _, err := resolvePolicy("acme/web", nil, repoPolicy); err == nil {
+		t.Error("expected a policy without filters to be refused")
+	}
+}
+
+func TestParsePolicyRejectsInvalidFiles(t *testing.T) {
+	if _, err := parsePolicy([]byte("filter-dayz: 30\n")); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+	if _, err := parsePolicy([]byte("repositories: ['acme/*']\n")); err == nil {
+		t.Error("expected an error for repositories in a policy file")
+	}
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Didstopia/githubby/ghapi"
+	"github.com/spf13/cobra"
+)
+
+var rateLimitCmd = &cobra.Command{
+	Use:   "rate-limit",
+	Short: "Show the GitHub API rate limits",
+	Long:  `Show the remaining requests and reset times for each GitHub API rate limit bucket (core, search, graphql, ..)`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Create a new GitHub client
+		owner, err := installationOwner()
+		logErrorAndExit(err)
+		client, err := newClient(owner)
+		if err != nil {
+			logErrorAndExit(err)
+		}
+
+		rateLimits, err := client.RateLimits(context.Background())
+		if err != nil {
+			logErrorAndExit(err)
+		}
+		printRateLimits(os.Stdout, rateLimits, time.Now())
+	},
+}
+
+// printRateLimits writes a table of the rate limit buckets, with reset times relative to now
+func printRateLimits(w io.Writer, rateLimits []ghapi.RateLimit, now time.Time) {
+	fmt.Fprintf(w, "%-24s %10s %10s  %s\n", "BUCKET", "REMAINING", "LIMIT", "RESETS")
+	for _, rateLimit := range rateLimits {
+		fmt.Fprintf(w, "%-24s %10d %10d  %s (in %s)\n", rateLimit.Name, rateLimit.Remaining, rateLimit.Limit, rateLimit.Reset.Local().Format("15:04:05"), rateLimit.Reset.Sub(now).Round(time.Second))
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Didstopia/githubby/ghapi"
+	"github.com/Didstopia/githubby/ghapi/ghapitest"
+)
+
+func TestPrintRateLimits(t *testing.T) {
+	// The API reports reset times in whole seconds
+	now := time.Now().Truncate(time.Second)
+	fake := ghapitest.NewFake()
+	fake.Limits = []ghapi.RateLimit{
+		{Name: "core", Limit: 5000, Remaining: 4321, Reset: now.Add(30 * time.Minute)},
+		{Name: "search", Limit: 30, Remaining: 0, Reset: now.Add(45 * time.Second)},
+	}
+	server := ghapitest.NewServer(fake)
+	defer server.Close()
+
+	defer func(baseURL string, token string) {
+		BaseURL, Token = baseURL, token
+	}(BaseURL, Token)
+	BaseURL, Token = server.BaseURL, "token"
+
+	client, err := newClient("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rateLimits, err := client.RateLimits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	printRateLimits(&output, rateLimits, now)
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "BUCKET") {
+		t.Fatalf("expected a header and two buckets, got %q", output.String())
+	}
+	if fields := strings.Fields(lines[1]); fields[0] != "core" || fields[1] != "4321" || fields[2] != "5000" || fields[len(fields)-1] != "30m0s)" {
+		t.Errorf("unexpected core bucket %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); fields[0] != "search" || fields[1] != "0" || fields[2] != "30" || fields[len(fields)-1] != "45s)" {
+		t.Errorf("unexpected search bucket %q", lines[2])
+	}
+}
+
+func TestInstallationOwner(t *testing.T) {
+	defer func(appID int64, installationID int64, owner string) {
+		AppID, AppInstallationID, Owner = appID, installationID, owner
+	}(AppID, AppInstallationID, Owner)
+
+	AppID, AppInstallationID, Owner = 123, 0, ""
+	if _, err := installationOwner(); err == nil || !strings.Contains(err.Error(), "app-installation-id") {
+		t.Errorf("expected the installation to be required for GitHub Apps, got %v", err)
+	}
+	AppID, AppInstallationID, Owner = 123, 0, "acme"
+	if owner, err := installationOwner(); err != nil || owner != "acme" {
+		t.Errorf("expected the owner, got %q (%v)", owner, err)
+	}
+	AppID, AppInstallationID, Owner = 123, 456, ""
+	if _, err := installationOwner(); err != nil {
+		t.Errorf("expected the installation ID to suffice, got %v", err)
+	}
+	AppID, AppInstallationID, Owner = 0, 0, ""
+	if _, err := installationOwner(); err != nil {
+		t.Errorf("expected tokens not to need an owner, got %v", err)
+	}
+}
@@ -2,25 +2,154 @@
 package cmd
 
 import (
-	"fmt"
 	"os"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra" // Include the Cobra Commander package
 )
 
+// ConfigFile is the path of the config file to use instead of searching the default locations
+var ConfigFile string
+
+// Profile is the named config file profile applied on top of the top-level config values
+var Profile string
+
+// VersionOutput is the output format of the version command ("text" or "json")
+var VersionOutput string
+
+// ConfigForce allows "config init" to overwrite an existing config file
+var ConfigForce bool
+
+// EnvFiles are the .env files to load environment variables from (existing variables take precedence)
+var EnvFiles []string
+
 // Verbose can be toggled on/off to enable diagnostic log output
 var Verbose bool
 
+// LogFormat is the format of the log output ("text" or "json")
+var LogFormat string
+
+// LogFile is the file to append the log output to instead of stderr
+var LogFile string
+
 // DryRun will simulate the cleanup process without actually deleting anything
 var DryRun bool
 
-// Repository is the target GitHub repository
-var Repository string
+// Repositories are the target GitHub repositories
+var Repositories []string
+
+// RepositoryFile is a file listing target GitHub repositories, one per line ("-" reads from stdin)
+var RepositoryFile string
+
+// Org selects all repositories of a GitHub organization
+var Org string
+
+// User selects all repositories owned by a GitHub user
+var User string
+
+// Topics only selects discovered repositories tagged with all of these topics
+var Topics []string
+
+// Names only selects discovered repositories whose name matches any of these glob patterns
+var Names []string
+
+// ExcludeArchived skips archived repositories during discovery
+var ExcludeArchived bool
+
+// FromPolicies selects all repositories listed literally in the config policies
+var FromPolicies bool
+
+// RepositoryPolicy enables reading policies from the target repository and its organization's ".github" repository
+var RepositoryPolicy bool
+
+// KeepTags protects releases whose tag matches any of these glob patterns
+var KeepTags []string
+
+// KeepPrereleases protects prereleases from being cleaned up
+var KeepPrereleases bool
+
+// KeepDrafts protects draft releases from being cleaned up
+var KeepDrafts bool
+
+// Action is what happens to releases matching the filters ("delete" or "delete-release")
+var Action string
+
+// AuditLog is the file destructive actions are recorded in (nothing is recorded when empty)
+var AuditLog string
+
+// WebhookURLs are the webhooks notified with a summary after cleaning up, optionally prefixed with their format (eg. "slack=https://..")
+var WebhookURLs []string
+
+// WebhookFormat is the payload format of webhooks without a format prefix ("json", "slack", "teams" or "template")
+var WebhookFormat string
+
+// WebhookTemplate is the path to a Go template file rendering the payload of the "template" webhook format
+var WebhookTemplate string
+
+// WebhookSecret is the key webhook payloads are signed with (HMAC-SHA256, not signed when empty)
+var WebhookSecret string
+
+// Remote is the git remote used to detect the repository when none is supplied
+var Remote string
 
 // Token is the GitHub API token
 var Token string
 
+// The provider the token was resolved from
+var tokenSource string
+
+// TokenFile is the path to a file containing the GitHub API token
+var TokenFile string
+
+// GitCredential enables looking up the token from git's credential helpers (git credential fill)
+var GitCredential bool
+
+// AppID is the ID of the GitHub App to authenticate as (instead of using a token)
+var AppID int64
+
+// AppPrivateKey is the path to the PEM encoded private key of the GitHub App
+var AppPrivateKey string
+
+// Owner is the organization or user whose GitHub App installation is used by commands that don't target a repository
+var Owner string
+
+// AppInstallationID is the ID of the GitHub App installation (looked up from the repository owner when unset)
+var AppInstallationID int64
+
+// BaseURL is the GitHub Enterprise Server API URL (empty for github.com)
+var BaseURL string
+
+// UploadURL is the GitHub Enterprise Server upload URL (derived from BaseURL when empty)
+var UploadURL string
+
+// RetryMaxAttempts is the maximum number of attempts for each GitHub API request
+var RetryMaxAttempts int
+
+// RetryBackoff is the initial delay between retries of failed GitHub API requests
+var RetryBackoff time.Duration
+
+// RetryMaxBackoff is the maximum delay between retries of failed GitHub API requests
+var RetryMaxBackoff time.Duration
+
+// Proxy is the proxy URL for GitHub API requests (the HTTPS_PROXY environment variable is used when empty)
+var Proxy string
+
+// CABundle is the path to a PEM file with additional certificates to trust for GitHub API requests
+var CABundle string
+
+// Insecure disables TLS certificate verification for GitHub API requests
+var Insecure bool
+
+// ConnectTimeout is the maximum time allowed for connecting to the GitHub API
+var ConnectTimeout time.Duration
+
+// RequestTimeout is the maximum time allowed for each GitHub API request attempt to respond (0 for no limit)
+var RequestTimeout time.Duration
+
+// TraceHTTP enables logging every GitHub API request and response
+var TraceHTTP bool
+
 // The primary logger
 var log = logrus.New()
 
@@ -38,48 +167,246 @@ func init() {
 		Long:  `A multi-purpose CLI utility for interacting with GitHub`,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
 			// Inject config file variables to all child commands
+			logErrorAndExit(profileErr)
 			injectViper(viperConfig, cmd)
 
-			// Validate token
-			if Token == "" {
-				fmt.Println("Missing required argument 'token'")
-				os.Exit(1)
-			}
-
-			// Validate repository
-			if Repository == "" {
-				fmt.Println("Missing required argument 'repository'")
-				os.Exit(1)
-			}
+			// Resolve and validate the credentials (the repository is validated by the commands that need one)
+			resolveCredentials()
 		},
 	}
 
 	// Add the clean command
 	rootCmd.AddCommand(cleanCmd)
 
+	// Add the auth command and its subcommands
+	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authLoginCmd)
+	authCmd.AddCommand(authLogoutCmd)
+	authCmd.AddCommand(authStatusCmd)
+
+	// Add the rate-limit command
+	rootCmd.AddCommand(rateLimitCmd)
+
+	// Add the version command
+	rootCmd.AddCommand(versionCmd)
+
+	// Add the config command and its subcommands
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configInitCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+
+	// Add the config file, profile and environment file flags globally (these select the config, so they aren't read from it)
+	rootCmd.PersistentFlags().StringVar(&ConfigFile, "config", "", "Path to the config file (defaults to $XDG_CONFIG_HOME/githubby/config.yaml or ~/.githubby.yaml)")
+	rootCmd.PersistentFlags().StringVar(&Profile, "profile", "", "Config file profile to apply (also read from "+profileEnv+")")
+	rootCmd.PersistentFlags().StringSliceVar(&EnvFiles, "env-file", nil, "Load environment variables from this .env file, can be repeated (variables that are already set take precedence)")
+
 	// FIXME: This is persisted to config, so can't be easily disabled
 	// Add the "verbose" flag globally, so it's available for all commands
 	rootCmd.PersistentFlags().BoolVarP(&Verbose, "verbose", "v", false, "Enable verbose output")
 	viperConfig.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
 	viperConfig.SetDefault("verbose", false)
 
+	// Add the "log-format" flag globally
+	rootCmd.PersistentFlags().StringVar(&LogFormat, "log-format", logFormatText, "Format of the log output (\"text\" or \"json\")")
+	viperConfig.BindPFlag("log-format", rootCmd.PersistentFlags().Lookup("log-format"))
+	viperConfig.SetDefault("log-format", logFormatText)
+
+	// Add the "log-file" flag globally
+	rootCmd.PersistentFlags().StringVar(&LogFile, "log-file", "", "Append the log output to this file instead of stderr")
+	viperConfig.BindPFlag("log-file", rootCmd.PersistentFlags().Lookup("log-file"))
+	viperConfig.SetDefault("log-file", "")
+
 	// FIXME: This is persisted to config, so can't be easily disabled
 	// Add the "dry-run" flag globally, so it's available for all commands
 	rootCmd.PersistentFlags().BoolVarP(&DryRun, "dry-run", "D", false, "Simulate running")
 	viperConfig.BindPFlag("dry-run", rootCmd.PersistentFlags().Lookup("dry-run"))
 	viperConfig.SetDefault("dry-run", false)
 
-	// Add the "token" flag globally, and mark it as always required
-	rootCmd.PersistentFlags().StringVarP(&Token, "token", "t", "", "GitHub API Token (required)")
-	rootCmd.MarkPersistentFlagRequired("token")
+	// Add the "token" flag globally
+	rootCmd.PersistentFlags().StringVarP(&Token, "token", "t", "", "GitHub API Token (looked up from the environment, --token-file, the credential store, git (with --git-credential) or gh when omitted)")
 	viperConfig.BindPFlag("token", rootCmd.PersistentFlags().Lookup("token"))
 	viperConfig.SetDefault("token", "")
 
-	// Add the "repository" flag to the clean command and mark it as always required
-	cleanCmd.Flags().StringVarP(&Repository, "repository", "r", "", "GitHub Repository (required, short format only, eg. user/repo)")
-	cleanCmd.MarkFlagRequired("repository")
+	// Add the "token-file" flag globally
+	rootCmd.PersistentFlags().StringVar(&TokenFile, "token-file", "", "Path to a file containing the GitHub API Token")
+	viperConfig.BindPFlag("token-file", rootCmd.PersistentFlags().Lookup("token-file"))
+	bindEnv("token-file", "GITHUBBY_TOKEN_FILE")
+	viperConfig.SetDefault("token-file", "")
+
+	// Add the "git-credential" flag globally
+	rootCmd.PersistentFlags().BoolVar(&GitCredential, "git-credential", false, "Look up the token from git's credential helpers (git credential fill) when none is supplied")
+	viperConfig.BindPFlag("git-credential", rootCmd.PersistentFlags().Lookup("git-credential"))
+	bindEnv("git-credential", "GITHUBBY_GIT_CREDENTIAL")
+	viperConfig.SetDefault("git-credential", false)
+
+	// Add the "app-id" flag globally
+	rootCmd.PersistentFlags().Int64Var(&AppID, "app-id", 0, "GitHub App ID to authenticate as instead of using a token")
+	viperConfig.BindPFlag("app-id", rootCmd.PersistentFlags().Lookup("app-id"))
+	bindEnv("app-id", "GITHUBBY_APP_ID")
+	viperConfig.SetDefault("app-id", 0)
+
+	// Add the "app-private-key" flag globally
+	rootCmd.PersistentFlags().StringVar(&AppPrivateKey, "app-private-key", "", "Path to the GitHub App private key PEM file (required with --app-id)")
+	viperConfig.BindPFlag("app-private-key", rootCmd.PersistentFlags().Lookup("app-private-key"))
+	bindEnv("app-private-key", "GITHUBBY_APP_PRIVATE_KEY")
+	viperConfig.SetDefault("app-private-key", "")
+
+	// Add the "app-installation-id" flag globally
+	rootCmd.PersistentFlags().Int64Var(&AppInstallationID, "app-installation-id", 0, "GitHub App installation ID (looked up from the repository owner by default)")
+	viperConfig.BindPFlag("app-installation-id", rootCmd.PersistentFlags().Lookup("app-installation-id"))
+	bindEnv("app-installation-id", "GITHUBBY_APP_INSTALLATION_ID")
+	viperConfig.SetDefault("app-installation-id", 0)
+
+	// Add the "base-url" flag globally
+	rootCmd.PersistentFlags().StringVar(&BaseURL, "base-url", "", "GitHub Enterprise Server API URL (eg. https://ghe.example.com/api/v3/)")
+	viperConfig.BindPFlag("base-url", rootCmd.PersistentFlags().Lookup("base-url"))
+	bindEnv("base-url", "GITHUBBY_BASE_URL")
+	viperConfig.SetDefault("base-url", "")
+
+	// Add the "upload-url" flag globally
+	rootCmd.PersistentFlags().StringVar(&UploadURL, "upload-url", "", "GitHub Enterprise Server upload URL (defaults to one derived from --base-url)")
+	viperConfig.BindPFlag("upload-url", rootCmd.PersistentFlags().Lookup("upload-url"))
+	bindEnv("upload-url", "GITHUBBY_UPLOAD_URL")
+	viperConfig.SetDefault("upload-url", "")
+
+	// Add the "retry-max-attempts" flag globally
+	rootCmd.PersistentFlags().IntVar(&RetryMaxAttempts, "retry-max-attempts", 3, "Maximum number of attempts for failed GitHub API requests (only requests safe to repeat are retried)")
+	viperConfig.BindPFlag("retry-max-attempts", rootCmd.PersistentFlags().Lookup("retry-max-attempts"))
+	viperConfig.SetDefault("retry-max-attempts", 3)
+
+	// Add the "retry-backoff" flag globally
+	rootCmd.PersistentFlags().DurationVar(&RetryBackoff, "retry-backoff", 1*time.Second, "Initial delay between retries of failed GitHub API requests")
+	viperConfig.BindPFlag("retry-backoff", rootCmd.PersistentFlags().Lookup("retry-backoff"))
+	viperConfig.SetDefault("retry-backoff", "1s")
+
+	// Add the "retry-max-backoff" flag globally
+	rootCmd.PersistentFlags().DurationVar(&RetryMaxBackoff, "retry-max-backoff", 30*time.Second, "Maximum delay between retries of failed GitHub API requests")
+	viperConfig.BindPFlag("retry-max-backoff", rootCmd.PersistentFlags().Lookup("retry-max-backoff"))
+	viperConfig.SetDefault("retry-max-backoff", "30s")
+
+	// Add the "proxy" flag globally
+	rootCmd.PersistentFlags().StringVar(&Proxy, "proxy", "", "Proxy URL for GitHub API requests (eg. http://proxy.example.com:3128, defaults to HTTPS_PROXY)")
+	viperConfig.BindPFlag("proxy", rootCmd.PersistentFlags().Lookup("proxy"))
+	viperConfig.SetDefault("proxy", "")
+
+	// Add the "ca-bundle" flag globally
+	rootCmd.PersistentFlags().StringVar(&CABundle, "ca-bundle", "", "Path to a PEM file with additional CA certificates to trust for GitHub API requests")
+	viperConfig.BindPFlag("ca-bundle", rootCmd.PersistentFlags().Lookup("ca-bundle"))
+	viperConfig.SetDefault("ca-bundle", "")
+
+	// Add the "insecure" flag globally
+	rootCmd.PersistentFlags().BoolVar(&Insecure, "insecure", false, "Skip TLS certificate verification for GitHub API requests (only for test instances)")
+	viperConfig.BindPFlag("insecure", rootCmd.PersistentFlags().Lookup("insecure"))
+	viperConfig.SetDefault("insecure", false)
+
+	// Add the "connect-timeout" flag globally
+	rootCmd.PersistentFlags().DurationVar(&ConnectTimeout, "connect-timeout", 30*time.Second, "Maximum time allowed for connecting to the GitHub API")
+	viperConfig.BindPFlag("connect-timeout", rootCmd.PersistentFlags().Lookup("connect-timeout"))
+	viperConfig.SetDefault("connect-timeout", "30s")
+
+	// Add the "request-timeout" flag globally
+	rootCmd.PersistentFlags().DurationVar(&RequestTimeout, "request-timeout", 0, "Maximum time allowed for each GitHub API request, including reading the response (0 for no limit)")
+	viperConfig.BindPFlag("request-timeout", rootCmd.PersistentFlags().Lookup("request-timeout"))
+	viperConfig.SetDefault("request-timeout", "0s")
+
+	// Add the "trace-http" flag globally
+	rootCmd.PersistentFlags().BoolVar(&TraceHTTP, "trace-http", false, "Log every GitHub API request and response (credentials are redacted)")
+	viperConfig.BindPFlag("trace-http", rootCmd.PersistentFlags().Lookup("trace-http"))
+	viperConfig.SetDefault("trace-http", false)
+
+	// Add the "repository" flag to the clean command (can be repeated)
+	cleanCmd.Flags().StringSliceVarP(&Repositories, "repository", "r", nil, "GitHub Repository, can be repeated (eg. user/repo, https://github.com/user/repo or git@github.com:user/repo.git, detected from the current git checkout when omitted)")
 	viperConfig.BindPFlag("repository", cleanCmd.Flags().Lookup("repository"))
-	viperConfig.SetDefault("repository", "")
+	viperConfig.SetDefault("repository", []string{})
+
+	// Add the "repository-file" flag to the clean command
+	cleanCmd.Flags().StringVar(&RepositoryFile, "repository-file", "", "File listing GitHub Repositories, one per line (\"-\" reads from stdin)")
+	viperConfig.BindPFlag("repository-file", cleanCmd.Flags().Lookup("repository-file"))
+	viperConfig.SetDefault("repository-file", "")
+
+	// Add the "org" flag to the clean command
+	cleanCmd.Flags().StringVar(&Org, "org", "", "Select all repositories of a GitHub organization")
+	viperConfig.BindPFlag("org", cleanCmd.Flags().Lookup("org"))
+	viperConfig.SetDefault("org", "")
+
+	// Add the "user" flag to the clean command
+	cleanCmd.Flags().StringVar(&User, "user", "", "Select all repositories owned by a GitHub user")
+	viperConfig.BindPFlag("user", cleanCmd.Flags().Lookup("user"))
+	viperConfig.SetDefault("user", "")
+
+	// Add the "topic" flag to the clean command (can be repeated)
+	cleanCmd.Flags().StringSliceVar(&Topics, "topic", nil, "Only select repositories with this topic, can be repeated (requires --org or --user)")
+	viperConfig.BindPFlag("topic", cleanCmd.Flags().Lookup("topic"))
+	viperConfig.SetDefault("topic", []string{})
+
+	// Add the "name" flag to the clean command (can be repeated)
+	cleanCmd.Flags().StringSliceVar(&Names, "name", nil, "Only select repositories whose name matches this glob pattern, can be repeated (eg. 'svc-*', requires --org or --user)")
+	viperConfig.BindPFlag("name", cleanCmd.Flags().Lookup("name"))
+	viperConfig.SetDefault("name", []string{})
+
+	// Add the "exclude-archived" flag to the clean command
+	cleanCmd.Flags().BoolVar(&ExcludeArchived, "exclude-archived", false, "Skip archived repositories (requires --org or --user)")
+	viperConfig.BindPFlag("exclude-archived", cleanCmd.Flags().Lookup("exclude-archived"))
+	viperConfig.SetDefault("exclude-archived", false)
+
+	// Add the "from-policies" flag to the clean command
+	cleanCmd.Flags().BoolVar(&FromPolicies, "from-policies", false, "Select all repositories listed (without patterns) in the config file policies")
+
+	// Add the "repository-policy" flag to the clean command
+	cleanCmd.Flags().BoolVar(&RepositoryPolicy, "repository-policy", false, "Read the policy from .github/githubby.yml in each repository, with defaults from githubby.yml in the owner's .github repository")
+	viperConfig.BindPFlag("repository-policy", cleanCmd.Flags().Lookup("repository-policy"))
+	viperConfig.SetDefault("repository-policy", false)
+
+	// Add the "keep-tags" flag to the clean command (can be repeated)
+	cleanCmd.Flags().StringSliceVar(&KeepTags, "keep-tags", nil, "Never clean up releases whose tag matches this glob pattern, can be repeated (eg. 'v1.*')")
+	viperConfig.BindPFlag("keep-tags", cleanCmd.Flags().Lookup("keep-tags"))
+	viperConfig.SetDefault("keep-tags", []string{})
+
+	// Add the "keep-prereleases" flag to the clean command
+	cleanCmd.Flags().BoolVar(&KeepPrereleases, "keep-prereleases", false, "Never clean up prereleases")
+	viperConfig.BindPFlag("keep-prereleases", cleanCmd.Flags().Lookup("keep-prereleases"))
+	viperConfig.SetDefault("keep-prereleases", false)
+
+	// Add the "keep-drafts" flag to the clean command
+	cleanCmd.Flags().BoolVar(&KeepDrafts, "keep-drafts", false, "Never clean up draft releases")
+	viperConfig.BindPFlag("keep-drafts", cleanCmd.Flags().Lookup("keep-drafts"))
+	viperConfig.SetDefault("keep-drafts", false)
+
+	// Add the "action" flag to the clean command
+	cleanCmd.Flags().StringVar(&Action, "action", actionDelete, "What to do with matching releases: \"delete\" (release and tag) or \"delete-release\" (keep the tag)")
+	viperConfig.BindPFlag("action", cleanCmd.Flags().Lookup("action"))
+	viperConfig.SetDefault("action", actionDelete)
+
+	// Add the "audit-log" flag to the clean command
+	cleanCmd.Flags().StringVar(&AuditLog, "audit-log", "", "Append a JSON line for every deleted release and tag to this file (not recorded by default)")
+	viperConfig.BindPFlag("audit-log", cleanCmd.Flags().Lookup("audit-log"))
+	viperConfig.SetDefault("audit-log", "")
+
+	// Add the "webhook-url" flag to the clean command (can be repeated)
+	cleanCmd.Flags().StringSliceVar(&WebhookURLs, "webhook-url", nil, "Post a summary to this webhook after cleaning up, can be repeated (prefix the URL with the format to override --webhook-format, eg. slack=https://hooks.slack.com/..)")
+	viperConfig.BindPFlag("webhook-url", cleanCmd.Flags().Lookup("webhook-url"))
+	viperConfig.SetDefault("webhook-url", []string{})
+
+	// Add the "webhook-format" flag to the clean command
+	cleanCmd.Flags().StringVar(&WebhookFormat, "webhook-format", webhookFormatJSON, "Webhook payload format: \"json\", \"slack\", \"teams\" or \"template\" (rendered from --webhook-template)")
+	viperConfig.BindPFlag("webhook-format", cleanCmd.Flags().Lookup("webhook-format"))
+	viperConfig.SetDefault("webhook-format", webhookFormatJSON)
+
+	// Add the "webhook-template" flag to the clean command
+	cleanCmd.Flags().StringVar(&WebhookTemplate, "webhook-template", "", "Path to a Go template file rendering the webhook payload for the \"template\" format")
+	viperConfig.BindPFlag("webhook-template", cleanCmd.Flags().Lookup("webhook-template"))
+	viperConfig.SetDefault("webhook-template", "")
+
+	// Add the "webhook-secret" flag to the clean command
+	cleanCmd.Flags().StringVar(&WebhookSecret, "webhook-secret", "", "Sign webhook payloads with this key, sending the HMAC-SHA256 signature in the "+webhookSignatureHeader+" header")
+	viperConfig.BindPFlag("webhook-secret", cleanCmd.Flags().Lookup("webhook-secret"))
+	viperConfig.SetDefault("webhook-secret", "")
+
+	// Add the "remote" flag to the clean command
+	cleanCmd.Flags().StringVar(&Remote, "remote", "origin", "Git remote to detect the repository from when --repository is omitted")
 
 	// Add the "filter-days" flag to the clean command
 	cleanCmd.Flags().Int64VarP(&FilterDays, "filter-days", "d", -1, "Filter based on maximum days since release (at least one filter is required)")
@@ -90,11 +417,22 @@ func init() {
 	cleanCmd.Flags().Int64VarP(&FilterCount, "filter-count", "c", -1, "Filter to cleanup releases over the set amount (at least one filter is required)")
 	viperConfig.BindPFlag("filter-count", cleanCmd.Flags().Lookup("filter-count"))
 	viperConfig.SetDefault("filter-count", -1)
+
+	// Add the "force" flag to the config init command
+	configInitCmd.Flags().BoolVar(&ConfigForce, "force", false, "Overwrite an existing config file")
+
+	// Add the "owner" flag to the commands that don't target a repository
+	authStatusCmd.Flags().StringVar(&Owner, "owner", "", "Organization or user to look up the GitHub App installation from (when --app-installation-id is omitted)")
+	rateLimitCmd.Flags().StringVar(&Owner, "owner", "", "Organization or user to look up the GitHub App installation from (when --app-installation-id is omitted)")
+
+	// Add the "output" flag to the version command
+	versionCmd.Flags().StringVarP(&VersionOutput, "output", "o", "text", "Output format: \"text\" or \"json\"")
 }
 
 // Execute starts the Cobra commander, which in turn will handle execution and any arguments
 func Execute() {
+	// Cobra already reports the error
 	if err := rootCmd.Execute(); err != nil {
-		panic(err)
+		os.Exit(1)
 	}
 }
@@ -1,9 +1,197 @@
 package cmd
 
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Didstopia/githubby/credentials"
+	"github.com/Didstopia/githubby/ghapi"
+	"github.com/Didstopia/githubby/util"
+	"github.com/sirupsen/logrus"
+)
+
+// logErrorAndExit logs the error and exits, also reporting it on stderr when logging to a file
 func logErrorAndExit(err error) {
 	if err != nil {
-		panic(err)
-		//fmt.Println(err)
-		//os.Exit(1)
+		log.Error(err)
+		if logFile != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// newClient creates the GitHub backend used by the commands (tests replace it to run the commands offline)
+var newClient = newGitHubClient
+
+// newGitHubClient creates a GitHub client based on the global flags,
+// using the owner to look up the GitHub App installation if needed
+func newGitHubClient(owner string) (ghapi.Backend, error) {
+	opts := []ghapi.Option{
+		ghapi.WithRetryPolicy(ghapi.RetryPolicy{
+			MaxAttempts:    RetryMaxAttempts,
+			InitialBackoff: RetryBackoff,
+			MaxBackoff:     RetryMaxBackoff,
+		}),
+		ghapi.WithUserAgent(userAgent()),
+		ghapi.WithTransport(transportConfig()),
+	}
+	if TraceHTTP {
+		opts = append(opts, ghapi.WithTrace(traceHTTP))
+	}
+	if BaseURL != "" {
+		opts = append(opts, ghapi.WithEnterpriseURLs(BaseURL, UploadURL))
+	}
+	if AppID != 0 {
+		privateKey, err := ioutil.ReadFile(AppPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, ghapi.WithAppAuthentication(ghapi.AppCredentials{
+			AppID:             AppID,
+			PrivateKey:        privateKey,
+			InstallationID:    AppInstallationID,
+			InstallationOwner: owner,
+		}))
+	}
+	return ghapi.NewGitHub(Token, opts...)
+}
+
+// transportConfig returns the proxy, TLS and timeout settings for outgoing HTTP requests
+func transportConfig() ghapi.TransportConfig {
+	return ghapi.TransportConfig{
+		ProxyURL:       Proxy,
+		CABundle:       CABundle,
+		Insecure:       Insecure,
+		ConnectTimeout: ConnectTimeout,
+		RequestTimeout: RequestTimeout,
+	}
+}
+
+// installationOwner returns the owner used to look up the GitHub App installation for commands that don't target a repository
+func installationOwner() (string, error) {
+	if AppID != 0 && AppInstallationID == 0 && Owner == "" {
+		return "", errors.New("missing required argument 'app-installation-id' (or 'owner') when authenticating as a GitHub App")
+	}
+	return Owner, nil
+}
+
+// traceHTTP logs a GitHub API request attempt and its outcome
+func traceHTTP(event ghapi.TraceEvent) {
+	entry := log.WithFields(logrus.Fields{
+		"method":   event.Method,
+		"url":      event.URL,
+		"duration": event.Duration.Round(time.Millisecond).String(),
+		"headers":  event.RequestHeader,
+	})
+	if event.Err != nil {
+		entry.WithError(event.Err).Info("HTTP request failed")
+		return
+	}
+	entry = entry.WithField("status", event.Status)
+	if event.RateLimit != "" {
+		entry = entry.WithFields(logrus.Fields{
+			"rate_limit":           event.RateLimit,
+			"rate_limit_remaining": event.RateLimitRemaining,
+			"rate_limit_reset":     event.RateLimitReset,
+		})
+	}
+	entry.Info("HTTP request")
+}
+
+// apiHost returns the host of the GitHub API in use (github.com unless an enterprise base URL is set)
+func apiHost() string {
+	if BaseURL != "" {
+		if parsedURL, err := url.Parse(BaseURL); err == nil && parsedURL.Host != "" {
+			return parsedURL.Host
+		}
+	}
+	return credentials.DefaultHost
+}
+
+// credentialStore returns the encrypted credential store, unlocked with the passphrase from the environment
+func credentialStore() (credentials.EncryptedStore, error) {
+	// Keep using a store in the legacy home directory location, otherwise store it next to the XDG config file
+	home, err := getHomePath()
+	if err != nil {
+		return credentials.EncryptedStore{}, err
+	}
+	path := filepath.Join(home, credentialStoreFileName)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		configDir, err := getConfigDir()
+		if err != nil {
+			return credentials.EncryptedStore{}, err
+		}
+		path = filepath.Join(configDir, xdgCredentialStoreFileName)
+	}
+	return credentials.EncryptedStore{
+		Path:       path,
+		Passphrase: os.Getenv(credentials.PassphraseEnv),
+	}, nil
+}
+
+// credentialChain returns the providers to look up the token from when it isn't explicitly supplied, in order
+func credentialChain() credentials.Chain {
+	chain := credentials.Chain{credentials.EnvProvider{}}
+	if TokenFile != "" {
+		chain = append(chain, credentials.FileProvider{Path: TokenFile})
+	}
+	// A token explicitly saved with "auth login" wins over the ones other tools manage
+	if store, err := credentialStore(); err == nil {
+		chain = append(chain, store)
+	}
+	// Git's credential helpers may hold tokens with other scopes (or show a GUI prompt), so they're opt-in
+	if GitCredential {
+		chain = append(chain, credentials.GitCredentialProvider{})
+	}
+	return append(chain, credentials.GHCLIProvider{})
+}
+
+// resolveCredentials falls back to the credential providers if no token was explicitly supplied
+// (not needed when authenticating as a GitHub App), exiting if no usable credentials are found
+func resolveCredentials() {
+	if Token != "" {
+		tokenSource = "flag or config file"
+	} else if AppID == 0 {
+		token, source, err := credentialChain().Token(apiHost())
+		if err != nil {
+			logErrorAndExit(fmt.Errorf("missing required argument 'token' (or 'app-id'): %w", err))
+		}
+		log.WithField("source", source).Debug("Using token")
+		Token = token
+		tokenSource = source
+	}
+
+	// Validate the GitHub App private key
+	if AppID != 0 {
+		if AppPrivateKey == "" {
+			logErrorAndExit(errors.New("missing required argument 'app-private-key' for 'app-id'"))
+		}
+		tokenSource = "GitHub App " + strconv.FormatInt(AppID, 10)
+	}
+}
+
+// parseRepository validates the supplied repository reference, making sure it belongs to the configured host
+func parseRepository(repository string) (string, string, error) {
+	return util.ValidateEnterpriseRepository(repository, apiHost())
+}
+
+// detectRepository returns the URL of the named git remote of the checkout containing the working directory
+func detectRepository(remote string) (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	remoteURL, err := util.FindGitRemoteURL(dir, remote)
+	if err != nil {
+		return "", err
 	}
+	log.WithFields(logrus.Fields{"repo": remoteURL, "remote": remote}).Debug("Detected repository from git remote")
+	return remoteURL, nil
 }
@@ -2,6 +2,6 @@ package cmd
 
 import "testing"
 
-func TestDummy(t *testing.T) {
+func TestUtilDummy(t *testing.T) {
 
 }
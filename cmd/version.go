@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/spf13/cobra"
+)
+
+// The build metadata, injected by the main package at build time
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// versionInfo is the build metadata reported by the version command
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+// SetVersion sets the build metadata (an empty version is looked up from the Go build information when available)
+func SetVersion(buildVersion string, buildCommit string, date string) {
+	if buildVersion != "" {
+		version = buildVersion
+	}
+	commit = buildCommit
+	buildDate = date
+	rootCmd.Version = getVersionInfo().Version
+}
+
+// getVersionInfo returns the build metadata, falling back to the module version from the Go build information
+// (eg. when installed with "go get")
+func getVersionInfo() versionInfo {
+	info := versionInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if buildInfo, ok := debug.ReadBuildInfo(); ok && info.Version == "dev" {
+		if buildInfo.Main.Version != "" && buildInfo.Main.Version != "(devel)" {
+			info.Version = buildInfo.Main.Version
+		}
+	}
+	return info
+}
+
+// userAgent returns the User-Agent sent with every GitHub API request (eg. "githubby/1.2.3 (linux/amd64; go1.13)")
+func userAgent() string {
+	info := getVersionInfo()
+	return "githubby/" + info.Version + " (" + info.Platform + "; " + info.GoVersion + ")"
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show the version",
+	Long:  `Show the version, commit, build date, Go version and platform of githubby`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Don't require a token or repository for showing the version
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		info := getVersionInfo()
+		switch VersionOutput {
+		case "json":
+			data, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				logErrorAndExit(err)
+			}
+			fmt.Println(string(data))
+		case "text":
+			fmt.Println("Version:   ", info.Version)
+			if info.Commit != "" {
+				fmt.Println("Commit:    ", info.Commit)
+			}
+			if info.BuildDate != "" {
+				fmt.Println("Build date:", info.BuildDate)
+			}
+			fmt.Println("Go version:", info.GoVersion)
+			fmt.Println("Platform:  ", info.Platform)
+		default:
+			logErrorAndExit(errors.New("unsupported output format \"" + VersionOutput + "\" (use \"text\" or \"json\")"))
+		}
+	},
+}
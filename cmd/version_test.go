@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestUserAgent(t *testing.T) {
+	defer func(previousVersion string) { version = previousVersion }(version)
+	version = "1.2.3"
+
+	expected := "githubby/1.2.3 (" + runtime.GOOS + "/" + runtime.GOARCH + "; " + runtime.Version() + ")"
+	if agent := userAgent(); agent != expected {
+		t.Errorf("expected %q, got %q", expected, agent)
+	}
+}
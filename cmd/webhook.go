@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Didstopia/githubby/ghapi"
+	"github.com/google/go-github/v24/github"
+)
+
+// The built-in webhook payload formats, along with a custom template read from --webhook-template
+const (
+	webhookFormatJSON     = "json"
+	webhookFormatSlack    = "slack"
+	webhookFormatTeams    = "teams"
+	webhookFormatTemplate = "template"
+)
+
+// The header carrying the HMAC-SHA256 signature of the payload (modelled after GitHub's own webhooks)
+const webhookSignatureHeader = "X-Githubby-Signature-256"
+
+// The maximum time allowed for delivering a webhook
+const webhookTimeout = 10 * time.Second
+
+// The templates of the built-in webhook payload formats
+var webhookTemplates = map[string]string{
+	webhookFormatJSON:  `{{ json . }}`,
+	webhookFormatSlack: `{"text": {{ json .Text }}}`,
+	webhookFormatTeams: `{"@type": "MessageCard", "@context": "https://schema.org/extensions", "summary": {{ json .Title }}, "themeColor": {{ if eq .Status "succeeded" }}"2EB886"{{ else }}"D00000"{{ end }}, "title": {{ json .Title }}, "text": {{ json (markdownLines .Text) }}}`,
+}
+
+// webhookSummary is the outcome of a clean run, which the webhook payload templates are rendered with
+type webhookSummary struct {
+	Status       string              `json:"status"`
+	DryRun       bool                `json:"dry_run"`
+	Host         string              `json:"host"`
+	StartedAt    time.Time           `json:"started_at"`
+	FinishedAt   time.Time           `json:"finished_at"`
+	Total        int                 `json:"total"`
+	Matched      int                 `json:"matched"`
+	Deleted      int                 `json:"deleted"`
+	Failed       int                 `json:"failed"`
+	Error        string              `json:"error,omitempty"`
+	Repositories []webhookRepository `json:"repositories"`
+}
+
+// webhookRepository is the outcome of cleaning up a single repository
+type webhookRepository struct {
+	Repository  string   `json:"repository"`
+	Total       int      `json:"total"`
+	Matched     int      `json:"matched"`
+	Deleted     int      `json:"deleted"`
+	Failed      int      `json:"failed"`
+	DeletedTags []string `json:"deleted_tags"`
+	FailedTags  []string `json:"failed_tags"`
+	Interrupted bool     `json:"interrupted"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// webhook is a single webhook to notify
+type webhook struct {
+	url      string
+	template *template.Template
+}
+
+// The outcomes of a clean run
+const (
+	webhookStatusSucceeded   = "succeeded"
+	webhookStatusFailed      = "failed"
+	webhookStatusInterrupted = "interrupted"
+)
+
+// newWebhooks parses the configured webhooks, each either a URL or a URL prefixed with its format (eg. "slack=https://..")
+func newWebhooks() ([]webhook, error) {
+	webhooks := make([]webhook, 0, len(WebhookURLs))
+	templates := make(map[string]*template.Template)
+	for _, entry := range WebhookURLs {
+		format, rawURL := WebhookFormat, entry
+		if separator := strings.Index(entry, "="); separator > 0 && !strings.Contains(entry[:separator], "://") {
+			format, rawURL = entry[:separator], entry[separator+1:]
+		}
+		if parsedURL, err := url.Parse(rawURL); err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+			return nil, errors.New("invalid webhook URL (expected eg. https://hooks.example.com/githubby or slack=https://hooks.slack.com/..)")
+		}
+
+		if templates[format] == nil {
+			payloadTemplate, err := webhookTemplate(format)
+			if err != nil {
+				return nil, err
+			}
+			templates[format] = payloadTemplate
+		}
+		webhooks = append(webhooks, webhook{url: rawURL, template: templates[format]})
+	}
+	return webhooks, nil
+}
+
+// webhookTemplate returns the payload template for a format
+func webhookTemplate(format string) (*template.Template, error) {
+	text, ok := webhookTemplates[format]
+	if format == webhookFormatTemplate {
+		if WebhookTemplate == "" {
+			return nil, errors.New("the 'template' webhook format requires 'webhook-template'")
+		}
+		content, err := ioutil.ReadFile(WebhookTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the webhook template: %w", err)
+		}
+		text, ok = string(content), true
+	}
+	if !ok {
+		return nil, fmt.Errorf("unsupported webhook format %q (use %q, %q, %q or %q)", format, webhookFormatJSON, webhookFormatSlack, webhookFormatTeams, webhookFormatTemplate)
+	}
+	payloadTemplate, err := template.New(format).Funcs(template.FuncMap{
+		"json":          webhookJSON,
+		"markdownLines": markdownLines,
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook template: %w", err)
+	}
+	return payloadTemplate, nil
+}
+
+// newWebhookSummary summarizes the results of a clean run, including the repositories skipped after an interrupt
+func newWebhookSummary(results []*cleanResult, skipped []string, startedAt time.Time) webhookSummary {
+	summary := webhookSummary{
+		Status:       webhookStatusSucceeded,
+		DryRun:       DryRun,
+		Host:         apiHost(),
+		StartedAt:    startedAt.UTC(),
+		FinishedAt:   time.Now().UTC(),
+		Repositories: make([]webhookRepository, 0, len(results)+len(skipped)),
+	}
+	for _, result := range results {
+		repository := webhookRepository{
+			Repository:  result.repository,
+			Total:       result.total,
+			Matched:     len(result.matched),
+			Deleted:     len(result.deleted),
+			Failed:      len(result.failed),
+			DeletedTags: releaseTags(result.deleted),
+			FailedTags:  releaseTags(result.failed),
+			Interrupted: result.interrupted,
+		}
+		if result.err != nil {
+			repository.Error = result.err.Error()
+		}
+		summary.Repositories = append(summary.Repositories, repository)
+	}
+	for _, repository := range skipped {
+		summary.Repositories = append(summary.Repositories, webhookRepository{Repository: repository, DeletedTags: []string{}, FailedTags: []string{}, Interrupted: true})
+	}
+
+	for _, repository := range summary.Repositories {
+		summary.Total += repository.Total
+		summary.Matched += repository.Matched
+		summary.Deleted += repository.Deleted
+		summary.Failed += repository.Failed
+		if repository.Interrupted {
+			summary.Status = webhookStatusInterrupted
+		} else if (repository.Error != "" || repository.Failed > 0) && summary.Status == webhookStatusSucceeded {
+			summary.Status = webhookStatusFailed
+		}
+	}
+	return summary
+}
+
+// newFailedWebhookSummary summarizes a run that failed before any repository was cleaned up
+func newFailedWebhookSummary(err error, startedAt time.Time) webhookSummary {
+	summary := newWebhookSummary(nil, nil, startedAt)
+	summary.Status = webhookStatusFailed
+	summary.Error = err.Error()
+	return summary
+}
+
+// Title returns a one line description of the run
+func (summary webhookSummary) Title() string {
+	title := "githubby cleanup " + summary.Status
+	if summary.DryRun {
+		title += " (dry run)"
+	}
+	return title
+}
+
+// Text returns a human readable description of the run, as used by the chat formats
+func (summary webhookSummary) Text() string {
+	lines := []string{fmt.Sprintf("%s: %d release(s) deleted, %d failed, %d matched of %d on %s", summary.Title(), summary.Deleted, summary.Failed, summary.Matched, summary.Total, summary.Host)}
+	if summary.Error != "" {
+		lines = append(lines, "error: "+summary.Error)
+	}
+	for _, repository := range summary.Repositories {
+		line := fmt.Sprintf("• %s: %d deleted, %d failed", repository.Repository, repository.Deleted, repository.Failed)
+		switch {
+		case repository.Error != "":
+			line = fmt.Sprintf("• %s: error: %s", repository.Repository, repository.Error)
+		case repository.Interrupted:
+			line += " (interrupted)"
+		}
+		if len(repository.DeletedTags) > 0 {
+			line += "\n  deleted: " + strings.Join(repository.DeletedTags, ", ")
+		}
+		if len(repository.FailedTags) > 0 {
+			line += "\n  failed: " + strings.Join(repository.FailedTags, ", ")
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// render renders the webhook payload for the summary
+func (hook webhook) render(summary webhookSummary) ([]byte, error) {
+	var payload bytes.Buffer
+	if err := hook.template.Execute(&payload, summary); err != nil {
+		return nil, fmt.Errorf("unable to render the webhook payload: %w", err)
+	}
+	return payload.Bytes(), nil
+}
+
+// deliver posts the payload to the webhook, signing it if a secret is configured
+func (hook webhook) deliver(client *http.Client, payload []byte) error {
+	req, err := http.NewRequest("POST", hook.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent())
+	if WebhookSecret != "" {
+		req.Header.Set(webhookSignatureHeader, signWebhookPayload(WebhookSecret, payload))
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		// The URL itself may be a secret (eg. Slack webhooks), so only the error is reported
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", res.Status)
+	}
+	return nil
+}
+
+// notifyWebhooks posts the summary of the run to every webhook, logging (but otherwise ignoring) failed deliveries
+func notifyWebhooks(webhooks []webhook, summary webhookSummary) {
+	client, err := ghapi.NewHTTPClient(transportConfig(), webhookTimeout)
+	if err != nil {
+		log.WithError(err).Error("Unable to notify the webhooks")
+		return
+	}
+	for _, hook := range webhooks {
+		hookLog := log.WithField("webhook", webhookHost(hook.url))
+		payload, err := hook.render(summary)
+		if err == nil {
+			err = hook.deliver(client, payload)
+		}
+		if err != nil {
+			hookLog.WithError(err).Error("Unable to notify the webhook")
+			continue
+		}
+		hookLog.Debug("Notified the webhook")
+	}
+}
+
+// notifyWebhooksAndExit reports a run that failed before cleaning up any repository to the webhooks, then exits
+func notifyWebhooksAndExit(webhooks []webhook, startedAt time.Time, err error) {
+	if err == nil {
+		return
+	}
+	if len(webhooks) > 0 {
+		notifyWebhooks(webhooks, newFailedWebhookSummary(err, startedAt))
+	}
+	logErrorAndExit(err)
+}
+
+// signWebhookPayload returns the HMAC-SHA256 signature of the payload, as sent in the signature header
+func signWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookHost returns the host of a webhook URL, which unlike the full URL is safe to log
+func webhookHost(rawURL string) string {
+	if parsedURL, err := url.Parse(rawURL); err == nil {
+		return parsedURL.Host
+	}
+	return ""
+}
+
+// webhookJSON encodes a value as JSON, for use in the payload templates
+func webhookJSON(value interface{}) (string, error) {
+	encoded, err := json.Marshal(value)
+	return string(encoded), err
+}
+
+// markdownLines turns line breaks into Markdown line breaks, for chat formats that collapse single line breaks
+func markdownLines(text string) string {
+	return strings.ReplaceAll(text, "\n", "  \n")
+}
+
+// releaseTags returns the tag of each release
+func releaseTags(releases []*github.RepositoryRelease) []string {
+	tags := make([]string, 0, len(releases))
+	for _, release := range releases {
+		tags = append(tags, release.GetTagName())
+	}
+	return tags
+}
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v24/github"
+)
+
+// restoreWebhookConfig returns a function restoring the current webhook configuration (eg. defer restoreWebhookConfig()())
+func restoreWebhookConfig() func() {
+	urls, format, template, secret := WebhookURLs, WebhookFormat, WebhookTemplate, WebhookSecret
+	return func() {
+		WebhookURLs, WebhookFormat, WebhookTemplate, WebhookSecret = urls, format, template, secret
+	}
+}
+
+// testWebhookSummary returns the summary of a run that deleted one release and failed to delete another
+func testWebhookSummary() webhookSummary {
+	results := []*cleanResult{{
+		repository: "acme/app",
+		total:      5,
+		matched:    []*github.RepositoryRelease{{TagName: github.String("v1.0.0")}, {TagName: github.String("v1.0.1")}},
+		deleted:    []*github.RepositoryRelease{{TagName: github.String("v1.0.0")}},
+		failed:     []*github.RepositoryRelease{{TagName: github.String("v1.0.1")}},
+	}}
+	return newWebhookSummary(results, nil, time.Now())
+}
+
+func TestNewWebhooks(t *testing.T) {
+	defer restoreWebhookConfig()()
+	WebhookFormat, WebhookTemplate = webhookFormatJSON, ""
+
+	WebhookURLs = []string{"https://hooks.example.com/githubby?token=a=b", "slack=https://hooks.slack.com/services/T0/B0/X"}
+	webhooks, err := newWebhooks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(webhooks) != 2 || webhooks[0].url != WebhookURLs[0] || webhooks[1].url != "https://hooks.slack.com/services/T0/B0/X" {
+		t.Errorf("unexpected webhooks %+v", webhooks)
+	}
+	if webhooks[0].template.Name() != webhookFormatJSON || webhooks[1].template.Name() != webhookFormatSlack {
+		t.Errorf("expected the default and the prefixed format, got %q and %q", webhooks[0].template.Name(), webhooks[1].template.Name())
+	}
+
+	for _, entry := range []string{"hooks.acme.test", "ftp://hooks.acme.test", "teams="} {
+		WebhookURLs = []string{entry}
+		if _, err := newWebhooks(); err == nil || strings.Contains(err.Error(), "hooks.acme.test") {
+			t.Errorf("expected %q to be rejected without revealing the URL, got %v", entry, err)
+		}
+	}
+	WebhookURLs = []string{"discord=https://hooks.example.com"}
+	if _, err := newWebhooks(); err == nil || !strings.Contains(err.Error(), "unsupported webhook format") {
+		t.Errorf("expected an unsupported format to be rejected, got %v", err)
+	}
+	WebhookURLs = []string{"template=https://hooks.example.com"}
+	if _, err := newWebhooks(); err == nil {
+		t.Error("expected the template format to require a template")
+	}
+}
+
+func TestNewWebhookSummary(t *testing.T) {
+	summary := testWebhookSummary()
+	if summary.Status != webhookStatusFailed || summary.Total != 5 || summary.Matched != 2 || summary.Deleted != 1 || summary.Failed != 1 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	if tags := summary.Repositories[0].DeletedTags; strings.Join(tags, ",") != "v1.0.0" {
+		t.Errorf("unexpected deleted tags %q", tags)
+	}
+
+	summary = newWebhookSummary([]*cleanResult{{repository: "acme/app"}}, []string{"acme/lib"}, time.Now())
+	if summary.Status != webhookStatusInterrupted || len(summary.Repositories) != 2 || !summary.Repositories[1].Interrupted {
+		t.Errorf("expected the skipped repositories to be reported as interrupted, got %+v", summary)
+	}
+
+	summary = newWebhookSummary([]*cleanResult{{repository: "acme/app", err: errors.New("404 Not Found")}}, nil, time.Now())
+	if summary.Status != webhookStatusFailed || summary.Repositories[0].Error != "404 Not Found" {
+		t.Errorf("expected the repository error to fail the run, got %+v", summary)
+	}
+	if !strings.Contains(summary.Text(), "acme/app: error: 404 Not Found") {
+		t.Errorf("expected the error in the text, got %q", summary.Text())
+	}
+}
+
+func TestWebhookTemplates(t *testing.T) {
+	defer restoreWebhookConfig()()
+	summary := testWebhookSummary()
+	summary.DryRun = true
+
+	for format := range webhookTemplates {
+		payloadTemplate, err := webhookTemplate(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload, err := webhook{template: payloadTemplate}.render(summary)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := make(map[string]interface{})
+		if err := json.Unmarshal(payload, &decoded); err != nil {
+			t.Errorf("expected the %s format to render valid JSON, got %s: %v", format, payload, err)
+		}
+		if format == webhookFormatJSON && (decoded["dry_run"] != true || decoded["status"] != webhookStatusFailed) {
+			t.Errorf("unexpected JSON payload %v", decoded)
+		}
+		if format == webhookFormatSlack && !strings.Contains(decoded["text"].(string), "githubby cleanup failed (dry run)") {
+			t.Errorf("unexpected Slack payload %v", decoded)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "githubby-webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	WebhookTemplate = filepath.Join(dir, "webhook.tmpl")
+	if err := ioutil.WriteFile(WebhookTemplate, []byte(`{"deleted": {{ .Deleted }}, "repo": {{ json (index .Repositories 0).Repository }}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	payloadTemplate, err := webhookTemplate(webhookFormatTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := webhook{template: payloadTemplate}.render(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(payload) != `{"deleted": 1, "repo": "acme/app"}` {
+		t.Errorf("unexpected custom payload %s", payload)
+	}
+}
+
+func TestNotifyWebhooksSignsPayload(t *testing.T) {
+	defer restoreWebhookConfig()()
+	WebhookFormat, WebhookSecret = webhookFormatJSON, "s3cret"
+
+	received := make(chan bool, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := ioutil.ReadAll(r.Body)
+		received <- r.Header.Get(webhookSignatureHeader) == signWebhookPayload("s3cret", payload) && r.Header.Get("Content-Type") == "application/json"
+	}))
+	defer server.Close()
+
+	WebhookURLs = []string{server.URL}
+	webhooks, err := newWebhooks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifyWebhooks(webhooks, testWebhookSummary())
+	if !<-received {
+		t.Error("expected a signed JSON payload")
+	}
+	if signature := signWebhookPayload("key", []byte("The quick brown fox jumps over the lazy dog")); signature != "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8" {
+		t.Errorf("unexpected signature %q", signature)
+	}
+}
+
+func TestNotifyWebhooksFailedRun(t *testing.T) {
+	defer restoreWebhookConfig()()
+	defer func(proxy string) {
+		Proxy = proxy
+	}(Proxy)
+	WebhookFormat, WebhookSecret = webhookFormatJSON, ""
+
+	received := make(chan webhookSummary, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		summary := webhookSummary{}
+		json.NewDecoder(r.Body).Decode(&summary)
+		received <- summary
+	}))
+	defer server.Close()
+
+	WebhookURLs = []string{server.URL}
+	webhooks, err := newWebhooks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := newFailedWebhookSummary(errors.New("unable to list the repositories"), time.Now())
+	if !strings.Contains(summary.Text(), "error: unable to list the repositories") {
+		t.Errorf("expected the error in the text, got %q", summary.Text())
+	}
+	notifyWebhooks(webhooks, summary)
+	if summary := <-received; summary.Status != webhookStatusFailed || summary.Error != "unable to list the repositories" || summary.Repositories == nil {
+		t.Errorf("expected a failed summary, got %+v", summary)
+	}
+
+	// The webhooks are delivered through the configured proxy
+	proxied := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied <- r.URL.String()
+	}))
+	defer proxy.Close()
+	Proxy, WebhookURLs = proxy.URL, []string{"http://hooks.acme.test/githubby"}
+	if webhooks, err = newWebhooks(); err != nil {
+		t.Fatal(err)
+	}
+	notifyWebhooks(webhooks, summary)
+	if url := <-proxied; url != WebhookURLs[0] {
+		t.Errorf("expected the webhook to be sent through the proxy, got %q", url)
+	}
+}
+
+func TestDeliverWebhookFailure(t *testing.T) {
+	defer restoreWebhookConfig()()
+	WebhookSecret = ""
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(webhookSignatureHeader) != "" {
+			t.Error("expected an unsigned payload without a secret")
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Timeout: webhookTimeout}
+	if err := (webhook{url: server.URL}).deliver(client, []byte("{}")); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("expected the response status to be reported, got %v", err)
+	}
+
+	// Connection errors don't reveal the URL
+	server.Close()
+	if err := (webhook{url: server.URL + "/secret"}).deliver(client, []byte("{}")); err == nil || strings.Contains(err.Error(), "/secret") {
+		t.Errorf("expected a connection error without the URL, got %v", err)
+	}
+}
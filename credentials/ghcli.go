@@ -0,0 +1,67 @@
+package credentials
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/mitchellh/go-homedir"
+	"gopkg.in/yaml.v2"
+)
+
+// GHCLIProvider reads the token stored by the GitHub CLI (gh) in its hosts file
+type GHCLIProvider struct {
+	// Path overrides the location of the hosts file
+	Path string
+}
+
+// Name returns the name of the provider
+func (provider GHCLIProvider) Name() string {
+	return "gh cli"
+}
+
+// Token returns the OAuth token gh has stored for the host
+// (tokens gh keeps in the system keyring instead are not available)
+func (provider GHCLIProvider) Token(host string) (string, error) {
+	path := provider.Path
+	if path == "" {
+		var err error
+		if path, err = ghHostsFilePath(); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	hosts := make(map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+	})
+	if err := yaml.Unmarshal(data, &hosts); err != nil {
+		return "", err
+	}
+	return hosts[host].OAuthToken, nil
+}
+
+// ghHostsFilePath returns the location of the gh hosts file, following the same rules as gh itself
+func ghHostsFilePath() (string, error) {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "hosts.yml"), nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh", "hosts.yml"), nil
+	}
+	if dir := os.Getenv("AppData"); runtime.GOOS == "windows" && dir != "" {
+		return filepath.Join(dir, "GitHub CLI", "hosts.yml"), nil
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "gh", "hosts.yml"), nil
+}
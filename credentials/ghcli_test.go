@@ -0,0 +1,33 @@
+package credentials
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGHCLIProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "githubby")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "hosts.yml")
+	hosts := "github.com:\n    user: someone\n    oauth_token: public\nghe.example.com:\n    user: someone\n"
+	if err := ioutil.WriteFile(path, []byte(hosts), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := GHCLIProvider{Path: path}
+	if token, err := provider.Token(DefaultHost); err != nil || token != "public" {
+		t.Errorf("expected token public, got %q (%v)", token, err)
+	}
+	if token, err := provider.Token("ghe.example.com"); err != nil || token != "" {
+		t.Errorf("expected no token for a keyring host, got %q (%v)", token, err)
+	}
+	if token, err := (GHCLIProvider{Path: filepath.Join(dir, "missing.yml")}).Token(DefaultHost); err != nil || token != "" {
+		t.Errorf("expected no token for a missing hosts file, got %q (%v)", token, err)
+	}
+}
@@ -0,0 +1,35 @@
+package credentials
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGitCredentialProvider(t *testing.T) {
+	provider := GitCredentialProvider{run: func(input string) ([]byte, error) {
+		if !strings.Contains(input, "host=ghe.example.com\n") {
+			t.Errorf("unexpected input %q", input)
+		}
+		return []byte("protocol=https\nhost=ghe.example.com\nusername=someone\npassword=secret\n"), nil
+	}}
+
+	token, err := provider.Token("ghe.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "secret" {
+		t.Errorf("expected token secret, got %q", token)
+	}
+}
+
+func TestGitCredentialProviderWithoutCredentials(t *testing.T) {
+	provider := GitCredentialProvider{run: func(input string) ([]byte, error) {
+		return nil, errors.New("exit status 128")
+	}}
+
+	token, err := provider.Token(DefaultHost)
+	if err != nil || token != "" {
+		t.Errorf("expected no token and no error, got %q and %v", token, err)
+	}
+}
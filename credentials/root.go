@@ -0,0 +1,104 @@
+// Package credentials provides a chain of sources for looking up GitHub API tokens.
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// DefaultHost is the host of the public GitHub API
+const DefaultHost = "github.com"
+
+// Provider looks up a GitHub API token for a host
+type Provider interface {
+	// Name returns a human readable name for the provider
+	Name() string
+
+	// Token returns the token for the supplied host, or an empty string if the provider has none
+	Token(host string) (string, error)
+}
+
+// Chain is an ordered list of providers, where the first one with a token wins
+type Chain []Provider
+
+// Token returns the first token found for the supplied host, along with the name of the provider it came from
+func (chain Chain) Token(host string) (string, string, error) {
+	for _, provider := range chain {
+		token, err := provider.Token(host)
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %w", provider.Name(), err)
+		}
+		if token != "" {
+			return token, provider.Name(), nil
+		}
+	}
+	return "", "", errors.New("no token found for " + host)
+}
+
+// EnvProvider reads the token from environment variables
+type EnvProvider struct{}
+
+// Name returns the name of the provider
+func (provider EnvProvider) Name() string {
+	return "environment"
+}
+
+// Token returns the token from the first non-empty environment variable, preferring
+// the enterprise specific variables for any host other than github.com
+func (provider EnvProvider) Token(host string) (string, error) {
+	variables := []string{"GITHUBBY_TOKEN", "GH_TOKEN", "GITHUB_TOKEN"}
+	if host != DefaultHost {
+		variables = []string{"GITHUBBY_TOKEN", "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+	}
+	for _, variable := range variables {
+		if token := strings.TrimSpace(os.Getenv(variable)); token != "" {
+			return token, nil
+		}
+	}
+	return "", nil
+}
+
+// FileProvider reads the token from a file that only its owner can access
+type FileProvider struct {
+	Path string
+}
+
+// Name returns the name of the provider
+func (provider FileProvider) Name() string {
+	return "token file"
+}
+
+// Token returns the trimmed contents of the token file, regardless of host
+func (provider FileProvider) Token(host string) (string, error) {
+	if provider.Path == "" {
+		return "", nil
+	}
+
+	// Refuse to use a token other users can read
+	info, err := os.Stat(provider.Path)
+	if err != nil {
+		return "", err
+	}
+	if err := checkPrivate(info); err != nil {
+		return "", fmt.Errorf("%s: %w", provider.Path, err)
+	}
+
+	data, err := ioutil.ReadFile(provider.Path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// checkPrivate returns an error if the file is accessible by anyone other than its owner
+// (file permissions are not meaningful on Windows, so the check is skipped there)
+func checkPrivate(info os.FileInfo) error {
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("permissions %#o are too open, restrict them to the owner only (eg. chmod 600)", info.Mode().Perm())
+	}
+	return nil
+}
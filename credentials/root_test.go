@@ -0,0 +1,97 @@
+package credentials
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// staticProvider is a provider returning a fixed token or error
+type staticProvider struct {
+	name  string
+	token string
+	err   error
+}
+
+func (provider staticProvider) Name() string {
+	return provider.name
+}
+
+func (provider staticProvider) Token(host string) (string, error) {
+	return provider.token, provider.err
+}
+
+func TestChainReturnsFirstToken(t *testing.T) {
+	chain := Chain{
+		staticProvider{name: "empty"},
+		staticProvider{name: "first", token: "one"},
+		staticProvider{name: "second", token: "two"},
+	}
+
+	token, source, err := chain.Token(DefaultHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "one" || source != "first" {
+		t.Errorf("expected token one from first, got %s from %s", token, source)
+	}
+}
+
+func TestChainFailsWithoutToken(t *testing.T) {
+	if _, _, err := (Chain{staticProvider{name: "empty"}}).Token(DefaultHost); err == nil {
+		t.Error("expected an error when no provider has a token")
+	}
+
+	if _, _, err := (Chain{staticProvider{name: "broken", err: errors.New("broken")}}).Token(DefaultHost); err == nil {
+		t.Error("expected provider errors to be returned")
+	}
+}
+
+func TestEnvProviderPrefersEnterpriseVariables(t *testing.T) {
+	for _, variable := range []string{"GITHUBBY_TOKEN", "GH_TOKEN", "GITHUB_TOKEN", "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"} {
+		defer os.Setenv(variable, os.Getenv(variable))
+		os.Unsetenv(variable)
+	}
+	os.Setenv("GITHUB_TOKEN", "public")
+	os.Setenv("GH_ENTERPRISE_TOKEN", "enterprise")
+
+	if token, _ := (EnvProvider{}).Token(DefaultHost); token != "public" {
+		t.Errorf("expected the public token, got %q", token)
+	}
+	if token, _ := (EnvProvider{}).Token("ghe.example.com"); token != "enterprise" {
+		t.Errorf("expected the enterprise token, got %q", token)
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "githubby")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(path, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	token, err := (FileProvider{Path: path}).Token(DefaultHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "secret" {
+		t.Errorf("expected token secret, got %q", token)
+	}
+
+	// World readable token files must be rejected
+	if runtime.GOOS != "windows" {
+		if err := os.Chmod(path, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := (FileProvider{Path: path}).Token(DefaultHost); err == nil {
+			t.Error("expected an error for a world readable token file")
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// PassphraseEnv is the environment variable holding the passphrase of the encrypted store
+const PassphraseEnv = "GITHUBBY_CREDENTIAL_PASSPHRASE"
+
+// The number of PBKDF2 iterations used to derive the encryption key from the passphrase
+const storeKeyIterations = 200000
+
+// The current version of the encrypted store file format
+const storeVersion = 1
+
+// ErrMissingPassphrase is returned when the encrypted store is used without a passphrase
+var ErrMissingPassphrase = errors.New("missing passphrase for the encrypted credential store (set " + PassphraseEnv + ")")
+
+// EncryptedStore keeps tokens per host in a passphrase encrypted file (AES-256-GCM)
+type EncryptedStore struct {
+	Path       string
+	Passphrase string
+}
+
+// storeFile is the on-disk format of the encrypted store
+type storeFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// Name returns the name of the provider
+func (store EncryptedStore) Name() string {
+	return "encrypted store"
+}
+
+// Token returns the stored token for the host (the store is skipped without a passphrase)
+func (store EncryptedStore) Token(host string) (string, error) {
+	if store.Passphrase == "" {
+		return "", nil
+	}
+	tokens, err := store.load()
+	if err != nil {
+		return "", err
+	}
+	return tokens[host], nil
+}
+
+// Set stores the token for the host, replacing any existing one
+func (store EncryptedStore) Set(host string, token string) error {
+	tokens, err := store.load()
+	if err != nil {
+		return err
+	}
+	tokens[host] = token
+	return store.save(tokens)
+}
+
+// Delete removes the token for the host, returning whether one was stored
+func (store EncryptedStore) Delete(host string) (bool, error) {
+	tokens, err := store.load()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := tokens[host]; !ok {
+		return false, nil
+	}
+	delete(tokens, host)
+	return true, store.save(tokens)
+}
+
+// load decrypts and returns all stored tokens (an empty map if the store doesn't exist yet)
+func (store EncryptedStore) load() (map[string]string, error) {
+	if store.Passphrase == "" {
+		return nil, ErrMissingPassphrase
+	}
+
+	tokens := make(map[string]string)
+	info, err := os.Stat(store.Path)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := checkPrivate(info); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(store.Path)
+	if err != nil {
+		return nil, err
+	}
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Version != storeVersion {
+		return nil, errors.New("unsupported credential store version")
+	}
+
+	gcm, err := newStoreCipher(store.Passphrase, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, errors.New("unable to decrypt the credential store (wrong passphrase?)")
+	}
+	if err := json.Unmarshal(plaintext, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// save encrypts the tokens with a fresh salt and nonce, writing them to a file only the owner can access
+func (store EncryptedStore) save(tokens map[string]string) error {
+	plaintext, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	file := storeFile{Version: storeVersion, Salt: make([]byte, 16)}
+	if _, err := io.ReadFull(rand.Reader, file.Salt); err != nil {
+		return err
+	}
+	gcm, err := newStoreCipher(store.Passphrase, file.Salt)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, file.Nonce); err != nil {
+		return err
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.Marshal(&file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(store.Path), 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(store.Path, data, 0600); err != nil {
+		return err
+	}
+
+	// WriteFile keeps the permissions of an existing file, so enforce them explicitly
+	return os.Chmod(store.Path, 0600)
+}
+
+// newStoreCipher derives the AES-256-GCM cipher from the passphrase and salt
+func newStoreCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2SHA256([]byte(passphrase), salt, storeKeyIterations, 32))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2SHA256 implements PBKDF2 (RFC 8018) with HMAC-SHA256 as the pseudorandom function
+func pbkdf2SHA256(password []byte, salt []byte, iterations int, keyLength int) []byte {
+	prf := hmac.New(sha256.New, password)
+	key := make([]byte, 0, keyLength)
+	counter := make([]byte, 4)
+	for block := uint32(1); len(key) < keyLength; block++ {
+		// U1 = PRF(password, salt || INT(block))
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(counter, block)
+		prf.Write(counter)
+		u := prf.Sum(nil)
+
+		// T = U1 ^ U2 ^ ... ^ Uc
+		t := make([]byte, len(u))
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLength]
+}
@@ -0,0 +1,74 @@
+package credentials
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPBKDF2SHA256(t *testing.T) {
+	// Test vectors for PBKDF2-HMAC-SHA256
+	tests := []struct {
+		iterations int
+		expected   string
+	}{
+		{1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+	}
+	for _, test := range tests {
+		key := hex.EncodeToString(pbkdf2SHA256([]byte("password"), []byte("salt"), test.iterations, 32))
+		if key != test.expected {
+			t.Errorf("%d iterations: expected %s, got %s", test.iterations, test.expected, key)
+		}
+	}
+}
+
+func TestEncryptedStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "githubby")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := EncryptedStore{Path: filepath.Join(dir, "credentials"), Passphrase: "passphrase"}
+	if err := store.Set(DefaultHost, "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The token must not be stored in plain text, nor be readable by others
+	data, err := ioutil.ReadFile(store.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Error("token stored in plain text")
+	}
+	if info, err := os.Stat(store.Path); err != nil {
+		t.Fatal(err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("expected permissions 0600, got %#o", info.Mode().Perm())
+	}
+
+	if token, err := store.Token(DefaultHost); err != nil || token != "secret" {
+		t.Errorf("expected token secret, got %q (%v)", token, err)
+	}
+
+	if _, err := (EncryptedStore{Path: store.Path, Passphrase: "wrong"}).Token(DefaultHost); err == nil {
+		t.Error("expected an error for the wrong passphrase")
+	}
+
+	if token, err := (EncryptedStore{Path: store.Path}).Token(DefaultHost); err != nil || token != "" {
+		t.Errorf("expected the store to be skipped without a passphrase, got %q (%v)", token, err)
+	}
+
+	if deleted, err := store.Delete(DefaultHost); err != nil || !deleted {
+		t.Errorf("expected the token to be deleted, got %v (%v)", deleted, err)
+	}
+	if token, err := store.Token(DefaultHost); err != nil || token != "" {
+		t.Errorf("expected no token after deletion, got %q (%v)", token, err)
+	}
+}
@@ -0,0 +1,176 @@
+package ghapi
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v24/github"
+	"golang.org/x/oauth2"
+)
+
+// How long each app JWT stays valid (GitHub allows at most 10 minutes)
+const appJWTLifetime = 9 * time.Minute
+
+// How long before expiry installation tokens are refreshed
+const installationTokenRefreshMargin = 5 * time.Minute
+
+// The timeout used when minting a new installation token
+const installationTokenTimeout = 30 * time.Second
+
+// AppCredentials holds what's needed to authenticate as a GitHub App installation
+type AppCredentials struct {
+	// AppID is the numeric ID of the GitHub App
+	AppID int64
+
+	// PrivateKey is the PEM encoded private key of the GitHub App
+	PrivateKey []byte
+
+	// InstallationID selects the installation explicitly (optional)
+	InstallationID int64
+
+	// InstallationOwner is the user or organization to look up the installation for,
+	// used when InstallationID isn't set
+	InstallationOwner string
+}
+
+// WithAppAuthentication authenticates as a GitHub App installation instead of using a static token
+func WithAppAuthentication(credentials AppCredentials) Option {
+	return func(opts *options) {
+		opts.app = &credentials
+	}
+}
+
+// parseAppPrivateKey decodes a PEM encoded RSA private key (PKCS#1 or PKCS#8)
+func parseAppPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("app private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse app private key: %w", err)
+	}
+	key, ok := parsedKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("app private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// signAppJWT creates a JWT identifying the app itself, signed with its private key (RS256)
+func signAppJWT(appID int64, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	// Backdate the issue time slightly to allow for clock drift
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// appTransport is a http.RoundTripper that authenticates requests as the app itself
+type appTransport struct {
+	base  http.RoundTripper
+	appID int64
+	key   *rsa.PrivateKey
+}
+
+// RoundTrip adds a freshly signed app JWT to the request before sending it
+func (transport *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	jwt, err := signAppJWT(transport.appID, transport.key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	authenticatedReq := req.Clone(req.Context())
+	authenticatedReq.Header.Set("Authorization", "Bearer "+jwt)
+	return transport.base.RoundTrip(authenticatedReq)
+}
+
+// installationTokenSource is an oauth2.TokenSource that mints GitHub App installation tokens
+type installationTokenSource struct {
+	appClient      *github.Client
+	owner          string
+	installationID int64
+	expiresAt      time.Time
+	mutex          sync.Mutex
+}
+
+// Token mints a new installation token, looking up the installation on first use
+func (source *installationTokenSource) Token() (*oauth2.Token, error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), installationTokenTimeout)
+	defer cancel()
+
+	// Find the installation for the target owner (organization first, then user)
+	if source.installationID == 0 {
+		if source.owner == "" {
+			return nil, errors.New("missing the installation ID or owner for the GitHub App")
+		}
+		installation, _, err := source.appClient.Apps.FindOrganizationInstallation(ctx, source.owner)
+		if err = WrapError(err); errors.Is(err, ErrNotFound) {
+			installation, _, err = source.appClient.Apps.FindUserInstallation(ctx, source.owner)
+			err = WrapError(err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to find a GitHub App installation for %q: %w", source.owner, err)
+		}
+		source.installationID = installation.GetID()
+	}
+
+	// Mint the installation token
+	installationToken, _, err := source.appClient.Apps.CreateInstallationToken(ctx, source.installationID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create a GitHub App installation token: %w", WrapError(err))
+	}
+
+	source.expiresAt = installationToken.GetExpiresAt()
+
+	// Report an earlier expiry, so the token gets refreshed well before it actually expires
+	return &oauth2.Token{
+		AccessToken: installationToken.GetToken(),
+		Expiry:      installationToken.GetExpiresAt().Add(-installationTokenRefreshMargin),
+	}, nil
+}
+
+// expiry returns when the most recently minted installation token expires
+func (source *installationTokenSource) expiry() time.Time {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	return source.expiresAt
+}
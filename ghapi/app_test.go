@@ -0,0 +1,132 @@
+package ghapi
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func generateAppKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func TestSignAppJWT(t *testing.T) {
+	key, _ := generateAppKey(t)
+	now := time.Unix(1500000000, 0)
+
+	jwt, err := signAppJWT(42, key, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 JWT segments, got %d", len(parts))
+	}
+
+	// Verify the signature
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("invalid JWT signature: %v", err)
+	}
+
+	// Verify the claims
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims struct {
+		IssuedAt  int64  `json:"iat"`
+		ExpiresAt int64  `json:"exp"`
+		Issuer    string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Issuer != "42" {
+		t.Errorf("expected issuer 42, got %s", claims.Issuer)
+	}
+	if claims.ExpiresAt-claims.IssuedAt > int64((10 * time.Minute).Seconds()) {
+		t.Errorf("JWT lifetime exceeds 10 minutes")
+	}
+}
+
+func TestParseAppPrivateKeyRejectsInvalidKeys(t *testing.T) {
+	if _, err := parseAppPrivateKey([]byte("not a key")); err == nil {
+		t.Error("expected an error for a non-PEM key")
+	}
+}
+
+func TestAppAuthenticationMintsInstallationTokens(t *testing.T) {
+	_, keyPEM := generateAppKey(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/orgs/someone/installation":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+		case "/api/v3/users/someone/installation":
+			fmt.Fprint(w, `{"id":7}`)
+		case "/api/v3/app/installations/7/access_tokens":
+			if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprintf(w, `{"token":"installation-token","expires_at":%q}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+		case "/api/v3/repos/someone/repo/releases":
+			if r.Header.Get("Authorization") != "Bearer installation-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `[{"id":1}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewGitHub("", WithEnterpriseURLs(server.URL+"/api/v3/", ""), WithAppAuthentication(AppCredentials{
+		AppID:             42,
+		PrivateKey:        keyPEM,
+		InstallationOwner: "someone",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	releases, err := client.GetReleases(context.Background(), "someone", "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(releases) != 1 {
+		t.Errorf("expected 1 release, got %d", len(releases))
+	}
+
+	identity, err := client.Identity(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.TokenType != TokenTypeInstallation || identity.Expiry == nil || identity.Expiry.Before(time.Now()) {
+		t.Errorf("unexpected installation identity: %+v", identity)
+	}
+}
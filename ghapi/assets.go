@@ -0,0 +1,40 @@
+package ghapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/go-github/v24/github"
+)
+
+// The maximum number of release assets the GitHub API returns per page
+const assetsPerPage = 100
+
+// ListReleaseAssets returns all assets attached to a release
+func (githubClient *GitHub) ListReleaseAssets(ctx context.Context, owner string, repo string, releaseID int64) ([]*github.ReleaseAsset, error) {
+	assets := make([]*github.ReleaseAsset, 0)
+	for page := 1; page > 0; {
+		pageAssets, res, err := githubClient.client.Repositories.ListReleaseAssets(ctx, owner, repo, releaseID, &github.ListOptions{Page: page, PerPage: assetsPerPage})
+		if err != nil {
+			return nil, WrapError(err)
+		}
+		assets = append(assets, pageAssets...)
+
+		// Move to the next page if there are any more pages left
+		if res.NextPage > page {
+			page = res.NextPage
+		} else {
+			page = 0
+		}
+	}
+	return assets, nil
+}
+
+// DeleteReleaseAsset will attempt to delete a release asset from GitHub (an asset that is already gone counts as deleted)
+func (githubClient *GitHub) DeleteReleaseAsset(ctx context.Context, owner string, repo string, assetID int64) error {
+	_, err := githubClient.client.Repositories.DeleteReleaseAsset(ctx, owner, repo, assetID)
+	if err = WrapError(err); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package ghapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReleaseAssets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v3/repos/owner/repo/releases/1/assets" && r.URL.Query().Get("page") == "1":
+			w.Header().Set("Link", `<http://`+r.Host+r.URL.Path+`?page=2>; rel="next"`)
+			w.Write([]byte(`[{"id": 10, "name": "app.tar.gz"}]`))
+		case r.Method == "GET" && r.URL.Path == "/api/v3/repos/owner/repo/releases/1/assets":
+			w.Write([]byte(`[{"id": 11, "name": "app.zip"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewGitHub("token", WithEnterpriseURLs(server.URL+"/api/v3/", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assets, err := client.ListReleaseAssets(context.Background(), "owner", "repo", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 2 || assets[0].GetID() != 10 || assets[1].GetID() != 11 {
+		t.Errorf("expected both pages of assets, got %v", assets)
+	}
+
+	// An asset that is already gone counts as deleted
+	if err := client.DeleteReleaseAsset(context.Background(), "owner", "repo", 10); err != nil {
+		t.Errorf("expected a missing asset to count as deleted, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package ghapi
+
+import (
+	"context"
+
+	"github.com/google/go-github/v24/github"
+)
+
+// ReleaseService lists and removes the releases of a repository
+type ReleaseService interface {
+	// IterateReleases returns an iterator for the releases of a repository (newest to oldest)
+	IterateReleases(ctx context.Context, owner string, repository string, options ReleaseIteratorOptions) *ReleaseIterator
+
+	// GetReleases returns all releases of a repository (newest to oldest)
+	GetReleases(ctx context.Context, owner string, repository string) ([]*github.RepositoryRelease, error)
+
+	// RemoveRelease deletes a release and its tag
+	RemoveRelease(ctx context.Context, owner string, repo string, release *github.RepositoryRelease) error
+
+	// RemoveReleaseOnly deletes a release, but keeps its tag
+	RemoveReleaseOnly(ctx context.Context, owner string, repo string, release *github.RepositoryRelease) error
+}
+
+// TagService looks up and removes the tags of a repository
+type TagService interface {
+	// GetTagSHA returns the SHA the tag points to
+	GetTagSHA(ctx context.Context, owner string, repo string, tag string) (string, error)
+
+	// DeleteTag deletes a tag
+	DeleteTag(ctx context.Context, owner string, repo string, tag string) error
+}
+
+// AssetService lists and removes the assets attached to releases
+type AssetService interface {
+	// ListReleaseAssets returns all assets attached to a release
+	ListReleaseAssets(ctx context.Context, owner string, repo string, releaseID int64) ([]*github.ReleaseAsset, error)
+
+	// DeleteReleaseAsset deletes a release asset
+	DeleteReleaseAsset(ctx context.Context, owner string, repo string, assetID int64) error
+}
+
+// RepositoryService finds repositories and reads files from them
+type RepositoryService interface {
+	// DiscoverRepositories lists all repositories of the selected organization or user that match the selector
+	DiscoverRepositories(ctx context.Context, selector RepositorySelector) ([]*github.Repository, error)
+
+	// GetFileContents returns the contents of a file in the repository's default branch, or false if it doesn't exist
+	GetFileContents(ctx context.Context, owner string, repo string, path string) ([]byte, bool, error)
+}
+
+// AccountService describes the authenticated identity and its limits
+type AccountService interface {
+	// Identity returns who the client authenticates as
+	Identity(ctx context.Context) (*Identity, error)
+
+	// Preflight verifies that the client is allowed to delete releases from a repository
+	Preflight(ctx context.Context, owner string, repo string) (*Identity, error)
+
+	// RateLimits returns the state of every rate limit bucket
+	RateLimits(ctx context.Context) ([]RateLimit, error)
+}
+
+// Backend is everything the commands need from GitHub, implemented by GitHub itself
+// and by the in-memory fake in the ghapitest package
+type Backend interface {
+	ReleaseService
+	TagService
+	AssetService
+	RepositoryService
+	AccountService
+}
+
+// Make sure GitHub implements the whole backend
+var _ Backend = (*GitHub)(nil)
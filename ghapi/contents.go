@@ -0,0 +1,31 @@
+package ghapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/go-github/v24/github"
+)
+
+// GetFileContents returns the contents of a file in the repository's default branch,
+// or false if the file (or the repository itself) doesn't exist
+func (githubClient *GitHub) GetFileContents(ctx context.Context, owner string, repo string, path string) ([]byte, bool, error) {
+	fileContent, _, _, err := githubClient.client.Repositories.GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{})
+	if err = WrapError(err); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	// A directory has no file content
+	if fileContent == nil {
+		return nil, false, nil
+	}
+
+	content, err := fileContent.GetContent()
+	if err != nil {
+		return nil, false, err
+	}
+	return []byte(content), true, nil
+}
@@ -0,0 +1,42 @@
+package ghapi
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetFileContents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/repos/owner/repo/contents/.github/githubby.yml":
+			fmt.Fprintf(w, `{"type":"file","encoding":"base64","content":%q}`, base64.StdEncoding.EncodeToString([]byte("filter-count: 10\n")))
+		case "/api/v3/repos/owner/repo/contents/.github":
+			fmt.Fprint(w, `[{"type":"file","name":"githubby.yml"}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewGitHub("token", WithEnterpriseURLs(server.URL+"/api/v3/", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, found, err := client.GetFileContents(context.Background(), "owner", "repo", ".github/githubby.yml")
+	if err != nil || !found || string(content) != "filter-count: 10\n" {
+		t.Errorf("expected the file contents, got %q, %v (%v)", content, found, err)
+	}
+
+	if _, found, err := client.GetFileContents(context.Background(), "owner", "repo", "missing.yml"); err != nil || found {
+		t.Errorf("expected a missing file not to be found, got %v (%v)", found, err)
+	}
+
+	if _, found, err := client.GetFileContents(context.Background(), "owner", "repo", ".github"); err != nil || found {
+		t.Errorf("expected a directory not to be found, got %v (%v)", found, err)
+	}
+}
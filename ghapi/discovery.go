@@ -0,0 +1,116 @@
+package ghapi
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v24/github"
+)
+
+// RepositorySelector selects repositories owned by an organization or user
+type RepositorySelector struct {
+	// Org selects the repositories of an organization
+	Org string
+
+	// User selects the repositories owned by a user (including private ones when it's the authenticated user)
+	User string
+
+	// Topics only keeps repositories tagged with all of the supplied topics
+	Topics []string
+
+	// Names only keeps repositories whose name matches any of the supplied glob patterns (eg. svc-*)
+	Names []string
+
+	// ExcludeArchived skips archived repositories, which can't be modified anyway
+	ExcludeArchived bool
+}
+
+// Matches checks if a repository passes the topic, name and archive filters of the selector
+func (selector RepositorySelector) Matches(repository *github.Repository) bool {
+	if selector.ExcludeArchived && repository.GetArchived() {
+		return false
+	}
+
+	// All topics must be present
+	for _, topic := range selector.Topics {
+		found := false
+		for _, repositoryTopic := range repository.Topics {
+			if strings.EqualFold(topic, repositoryTopic) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// Any of the name patterns must match
+	if len(selector.Names) == 0 {
+		return true
+	}
+	for _, pattern := range selector.Names {
+		if matched, err := path.Match(pattern, repository.GetName()); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscoverRepositories lists all repositories of the selected organization or user that match the selector
+func (githubClient *GitHub) DiscoverRepositories(ctx context.Context, selector RepositorySelector) ([]*github.Repository, error) {
+	if (selector.Org == "") == (selector.User == "") {
+		return nil, errors.New("exactly one of organization or user must be selected")
+	}
+
+	// Validate the name patterns up front, as path.Match only reports them while matching
+	for _, pattern := range selector.Names {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errors.New("invalid repository name pattern \"" + pattern + "\"")
+		}
+	}
+
+	// The authenticated user's private repositories are only listed through a separate endpoint
+	listOwnRepositories := false
+	if selector.User != "" {
+		if identity, err := githubClient.Identity(ctx); err == nil && strings.EqualFold(identity.Login, selector.User) {
+			listOwnRepositories = true
+		}
+	}
+
+	repositories := make([]*github.Repository, 0)
+	for page := 1; page > 0; {
+		var pageRepositories []*github.Repository
+		var res *github.Response
+		var err error
+		listOptions := github.ListOptions{Page: page, PerPage: 100}
+		switch {
+		case selector.Org != "":
+			pageRepositories, res, err = githubClient.client.Repositories.ListByOrg(ctx, selector.Org, &github.RepositoryListByOrgOptions{Type: "all", ListOptions: listOptions})
+		case listOwnRepositories:
+			pageRepositories, res, err = githubClient.client.Repositories.List(ctx, "", &github.RepositoryListOptions{Affiliation: "owner", ListOptions: listOptions})
+		default:
+			pageRepositories, res, err = githubClient.client.Repositories.List(ctx, selector.User, &github.RepositoryListOptions{Type: "owner", ListOptions: listOptions})
+		}
+		if err != nil {
+			return nil, WrapError(err)
+		}
+
+		for _, repository := range pageRepositories {
+			if selector.Matches(repository) {
+				repositories = append(repositories, repository)
+			}
+		}
+
+		// Move to the next page if there are any more pages left
+		if res.NextPage > page {
+			page = res.NextPage
+		} else {
+			page = 0
+		}
+	}
+
+	return repositories, nil
+}
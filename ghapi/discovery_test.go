@@ -0,0 +1,90 @@
+package ghapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v24/github"
+)
+
+func TestRepositorySelectorMatches(t *testing.T) {
+	name, archived := "svc-api", true
+	repository := &github.Repository{Name: &name, Topics: []string{"go", "release-managed"}}
+	archivedRepository := &github.Repository{Name: &name, Archived: &archived}
+
+	tests := []struct {
+		name       string
+		selector   RepositorySelector
+		repository *github.Repository
+		expected   bool
+	}{
+		{"no filters", RepositorySelector{}, repository, true},
+		{"matching topic", RepositorySelector{Topics: []string{"Release-Managed"}}, repository, true},
+		{"missing topic", RepositorySelector{Topics: []string{"release-managed", "java"}}, repository, false},
+		{"matching name", RepositorySelector{Names: []string{"web-*", "svc-*"}}, repository, true},
+		{"mismatching name", RepositorySelector{Names: []string{"web-*"}}, repository, false},
+		{"archived", RepositorySelector{ExcludeArchived: true}, archivedRepository, false},
+		{"archived included", RepositorySelector{}, archivedRepository, true},
+	}
+	for _, test := range tests {
+		if matched := test.selector.Matches(test.repository); matched != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, matched)
+		}
+	}
+}
+
+func TestDiscoverRepositories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/orgs/acme/repos" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("page") != "2" {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=2>; rel="next"`, r.Host, r.URL.Path))
+			fmt.Fprint(w, `[{"name":"svc-one","topics":["release-managed"]},{"name":"web","topics":["release-managed"]}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"name":"svc-two","topics":["release-managed"],"archived":true},{"name":"svc-three","topics":["release-managed"]}]`)
+	}))
+	defer server.Close()
+
+	client, err := NewGitHub("token", WithEnterpriseURLs(server.URL+"/api/v3/", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repositories, err := client.DiscoverRepositories(context.Background(), RepositorySelector{
+		Org:             "acme",
+		Topics:          []string{"release-managed"},
+		Names:           []string{"svc-*"},
+		ExcludeArchived: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0)
+	for _, repository := range repositories {
+		names = append(names, repository.GetName())
+	}
+	if strings.Join(names, ",") != "svc-one,svc-three" {
+		t.Errorf("expected svc-one,svc-three, got %v", names)
+	}
+}
+
+func TestDiscoverRepositoriesRequiresOneOwner(t *testing.T) {
+	client, err := NewGitHub("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DiscoverRepositories(context.Background(), RepositorySelector{}); err == nil {
+		t.Error("expected an error without an organization or user")
+	}
+	if _, err := client.DiscoverRepositories(context.Background(), RepositorySelector{Org: "acme", User: "someone"}); err == nil {
+		t.Error("expected an error with both an organization and user")
+	}
+}
@@ -0,0 +1,152 @@
+package ghapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v24/github"
+)
+
+// The kinds of errors returned by the GitHub API, which errors returned by this package can be checked against with errors.Is
+var (
+	// ErrNotFound means the resource doesn't exist, or the token has no access to it
+	ErrNotFound = errors.New("not found")
+
+	// ErrUnauthorized means the token is invalid or has expired
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrForbidden means the token isn't allowed to perform the operation
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrRateLimited means a primary or secondary rate limit was exceeded
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrValidation means the API rejected the request (eg. deleting a tag protected by a ruleset)
+	ErrValidation = errors.New("validation failed")
+)
+
+// APIError is an error returned by the GitHub API, classified by its Kind and wrapping the original go-github error
+type APIError struct {
+	// Kind is one of the sentinel errors (ErrNotFound, ErrUnauthorized, ErrForbidden, ErrRateLimited or ErrValidation)
+	Kind error
+
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+
+	// Message is the error message reported by the API
+	Message string
+
+	// RetryAfter is when the request may be retried (only set when rate limited, and zero if unknown)
+	RetryAfter time.Time
+
+	// Err is the original error returned by go-github
+	Err error
+}
+
+// Error returns the message of the original error
+func (apiError *APIError) Error() string {
+	return apiError.Err.Error()
+}
+
+// Unwrap returns the original error, so it remains available to errors.As
+func (apiError *APIError) Unwrap() error {
+	return apiError.Err
+}
+
+// Is matches the kind of the error (eg. errors.Is(err, ErrNotFound))
+func (apiError *APIError) Is(target error) bool {
+	return target == apiError.Kind
+}
+
+// FieldError describes why the API rejected a single field of a request
+type FieldError struct {
+	Resource string
+	Field    string
+	Code     string
+	Message  string
+}
+
+// ValidationError is an APIError of the ErrValidation kind, detailing the rejected fields
+type ValidationError struct {
+	*APIError
+
+	// Fields are the rejected fields (empty if the API didn't report any)
+	Fields []FieldError
+}
+
+// Unwrap returns the APIError, so both the ValidationError and the APIError are available to errors.As
+func (validationError *ValidationError) Unwrap() error {
+	return validationError.APIError
+}
+
+// WrapError classifies an error returned by go-github, wrapping it in an APIError (or ValidationError) when it's
+// one of the known kinds and returning it unchanged otherwise, so other backends can report errors the same way
+func WrapError(err error) error {
+	var apiError *APIError
+	if err == nil || errors.As(err, &apiError) {
+		return err
+	}
+
+	switch typedErr := err.(type) {
+	case *github.RateLimitError:
+		return &APIError{Kind: ErrRateLimited, StatusCode: statusCode(typedErr.Response), Message: typedErr.Message, RetryAfter: typedErr.Rate.Reset.Time, Err: err}
+	case *github.AbuseRateLimitError:
+		apiError = &APIError{Kind: ErrRateLimited, StatusCode: statusCode(typedErr.Response), Message: typedErr.Message, Err: err}
+		if retryAfter := typedErr.GetRetryAfter(); retryAfter > 0 {
+			apiError.RetryAfter = time.Now().Add(retryAfter)
+		}
+		return apiError
+	case *github.ErrorResponse:
+		apiError = &APIError{StatusCode: statusCode(typedErr.Response), Message: typedErr.Message, Err: err}
+		switch {
+		case apiError.StatusCode == http.StatusNotFound:
+			apiError.Kind = ErrNotFound
+		case apiError.StatusCode == http.StatusUnauthorized:
+			apiError.Kind = ErrUnauthorized
+		case apiError.StatusCode == http.StatusForbidden && strings.Contains(strings.ToLower(typedErr.Message), "rate limit"):
+			apiError.Kind = ErrRateLimited
+		case apiError.StatusCode == http.StatusForbidden:
+			apiError.Kind = ErrForbidden
+		case apiError.StatusCode == http.StatusUnprocessableEntity:
+			apiError.Kind = ErrValidation
+			validationError := &ValidationError{APIError: apiError, Fields: make([]FieldError, 0, len(typedErr.Errors))}
+			for _, fieldErr := range typedErr.Errors {
+				validationError.Fields = append(validationError.Fields, FieldError{
+					Resource: fieldErr.Resource,
+					Field:    fieldErr.Field,
+					Code:     fieldErr.Code,
+					Message:  fieldErr.Message,
+				})
+			}
+			return validationError
+		default:
+			return err
+		}
+		return apiError
+	}
+	return err
+}
+
+// describedError replaces the message of an error with a more helpful one, while keeping it available to errors.Is/As
+type describedError struct {
+	message string
+	err     error
+}
+
+func (err *describedError) Error() string {
+	return err.message
+}
+
+func (err *describedError) Unwrap() error {
+	return err.err
+}
+
+// statusCode returns the status code of a response (0 if there is none)
+func statusCode(res *http.Response) int {
+	if res == nil {
+		return 0
+	}
+	return res.StatusCode
+}
@@ -0,0 +1,124 @@
+package ghapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v24/github"
+)
+
+func TestWrapErrorClassifiesResponses(t *testing.T) {
+	req, _ := http.NewRequest("DELETE", "https://api.github.com/repos/owner/repo/git/refs/tags/v1.0.0", nil)
+	response := func(status int) *http.Response {
+		return &http.Response{StatusCode: status, Request: req}
+	}
+	errorResponse := func(status int, message string) error {
+		return &github.ErrorResponse{Response: response(status), Message: message}
+	}
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"not found", errorResponse(http.StatusNotFound, "Not Found"), ErrNotFound},
+		{"unauthorized", errorResponse(http.StatusUnauthorized, "Bad credentials"), ErrUnauthorized},
+		{"forbidden", errorResponse(http.StatusForbidden, "Resource not accessible by integration"), ErrForbidden},
+		{"secondary rate limit", errorResponse(http.StatusForbidden, "You have exceeded a secondary rate limit."), ErrRateLimited},
+		{"validation", errorResponse(http.StatusUnprocessableEntity, "Validation Failed"), ErrValidation},
+		{"rate limit", &github.RateLimitError{Response: response(http.StatusForbidden)}, ErrRateLimited},
+		{"abuse rate limit", &github.AbuseRateLimitError{Response: response(http.StatusForbidden)}, ErrRateLimited},
+	}
+	for _, test := range tests {
+		err := WrapError(test.err)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, err)
+		}
+		if !errors.Is(err, test.err) || err.Error() != test.err.Error() {
+			t.Errorf("%s: expected the original error to be wrapped, got %v", test.name, err)
+		}
+		if WrapError(err) != err {
+			t.Errorf("%s: expected wrapping twice to be a no-op", test.name)
+		}
+	}
+}
+
+func TestWrapErrorKeepsOtherErrors(t *testing.T) {
+	if WrapError(nil) != nil {
+		t.Error("expected nil to stay nil")
+	}
+	serverError := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusInternalServerError}}
+	if err := WrapError(serverError); err != serverError {
+		t.Errorf("expected a server error to be returned unchanged, got %T", err)
+	}
+	otherError := errors.New("connection refused")
+	if err := WrapError(otherError); err != otherError {
+		t.Errorf("expected a non API error to be returned unchanged, got %T", err)
+	}
+}
+
+func TestWrapErrorRateLimitRetryAfter(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	var apiError *APIError
+	err := WrapError(&github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: reset}}, Response: &http.Response{StatusCode: http.StatusForbidden}})
+	if !errors.As(err, &apiError) || !apiError.RetryAfter.Equal(reset) {
+		t.Errorf("expected to retry after %v, got %v", reset, err)
+	}
+
+	retryAfter := time.Minute
+	err = WrapError(&github.AbuseRateLimitError{RetryAfter: &retryAfter, Response: &http.Response{StatusCode: http.StatusForbidden}})
+	if !errors.As(err, &apiError) || apiError.RetryAfter.Before(time.Now().Add(retryAfter-time.Second)) {
+		t.Errorf("expected to retry after about %v, got %v", retryAfter, err)
+	}
+}
+
+func TestDeleteTagReportsValidationFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message":"Repository rule violations found","errors":[{"resource":"Ref","field":"ref","code":"custom","message":"Cannot delete this tag"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewGitHub("token", WithEnterpriseURLs(server.URL+"/api/v3/", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.DeleteTag(context.Background(), "owner", "repo", "v1.0.0")
+	var validationError *ValidationError
+	if !errors.Is(err, ErrValidation) || !errors.As(err, &validationError) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if validationError.StatusCode != http.StatusUnprocessableEntity || validationError.Message != "Repository rule violations found" {
+		t.Errorf("unexpected validation error %+v", validationError.APIError)
+	}
+	expected := FieldError{Resource: "Ref", Field: "ref", Code: "custom", Message: "Cannot delete this tag"}
+	if len(validationError.Fields) != 1 || validationError.Fields[0] != expected {
+		t.Errorf("expected fields %v, got %v", []FieldError{expected}, validationError.Fields)
+	}
+}
+
+func TestPreflightMissingRepositoryIsNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/user" {
+			w.Write([]byte(`{"login":"octocat"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, err := NewGitHub("token", WithEnterpriseURLs(server.URL+"/api/v3/", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Preflight(context.Background(), "owner", "repo")
+	if !errors.Is(err, ErrNotFound) || err.Error() != "repository owner/repo does not exist or the token has no access to it" {
+		t.Errorf("expected a descriptive not found error, got %v", err)
+	}
+}
@@ -0,0 +1,532 @@
+package ghapitest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Didstopia/githubby/ghapi"
+	"github.com/google/go-github/v24/github"
+)
+
+// Fake is an in-memory GitHub backend, with support for injecting faults into each operation
+type Fake struct {
+	// Login is the user the fake authenticates as
+	Login string
+
+	// Scopes are the OAuth scopes of the token (nil for tokens without scopes, such as fine-grained tokens)
+	Scopes []string
+
+	// Limits are the rate limits reported by the fake
+	Limits []ghapi.RateLimit
+
+	mutex        sync.Mutex
+	repositories map[string]*fakeRepository
+	nextID       int64
+	faults       map[Operation]*injectedFault
+}
+
+// fakeRepository is the state of a single repository
+type fakeRepository struct {
+	repository *github.Repository
+	releases   []*github.RepositoryRelease
+	tags       map[string]string
+	assets     map[int64][]*github.ReleaseAsset
+	files      map[string][]byte
+}
+
+// Make sure Fake implements the whole backend
+var _ ghapi.Backend = (*Fake)(nil)
+
+// NewFake creates an empty fake, authenticating as "octocat" with a token that has the "repo" scope
+func NewFake() *Fake {
+	return &Fake{
+		Login:        "octocat",
+		Scopes:       []string{"repo"},
+		Limits:       []ghapi.RateLimit{{Name: "core", Limit: 5000, Remaining: 5000, Reset: time.Now().Add(time.Hour)}},
+		repositories: make(map[string]*fakeRepository),
+		faults:       make(map[Operation]*injectedFault),
+	}
+}
+
+// Fail injects a fault into the next calls of an operation (every call from now on if times is 0 or less)
+func (fake *Fake) Fail(operation Operation, fault Fault, times int) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.faults[operation] = &injectedFault{fault: fault, remaining: times}
+}
+
+// AddRepository adds a repository the authenticated user has admin permission on, returning it for further changes
+func (fake *Fake) AddRepository(owner string, name string) *github.Repository {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	return fake.addRepository(owner, name).repository
+}
+
+// AddRelease adds a release along with its tag to a repository (which is added if it doesn't exist yet)
+func (fake *Fake) AddRelease(owner string, repo string, tag string, createdAt time.Time) *github.RepositoryRelease {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	repository := fake.addRepository(owner, repo)
+	release := &github.RepositoryRelease{
+		ID:        github.Int64(fake.newID()),
+		TagName:   github.String(tag),
+		Name:      github.String(tag),
+		CreatedAt: &github.Timestamp{Time: createdAt},
+	}
+	repository.releases = append(repository.releases, release)
+	repository.tags[tag] = tagSHA(release.GetID())
+	return release
+}
+
+// AddAsset attaches an asset to a release (also listed in the release itself, like the GitHub API does)
+func (fake *Fake) AddAsset(owner string, repo string, releaseID int64, name string) *github.ReleaseAsset {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	repository := fake.addRepository(owner, repo)
+	asset := &github.ReleaseAsset{ID: github.Int64(fake.newID()), Name: github.String(name)}
+	repository.assets[releaseID] = append(repository.assets[releaseID], asset)
+	repository.syncReleaseAssets(releaseID)
+	return asset
+}
+
+// SetFile sets the contents of a file in the default branch of a repository
+func (fake *Fake) SetFile(owner string, repo string, path string, content []byte) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.addRepository(owner, repo).files[path] = content
+}
+
+// Releases returns the releases of a repository (newest to oldest)
+func (fake *Fake) Releases(owner string, repo string) []*github.RepositoryRelease {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	if repository := fake.repository(owner, repo); repository != nil {
+		return sortedReleases(repository.releases)
+	}
+	return nil
+}
+
+// Tags returns the tags of a repository, sorted by name
+func (fake *Fake) Tags(owner string, repo string) []string {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	tags := make([]string, 0)
+	if repository := fake.repository(owner, repo); repository != nil {
+		for tag := range repository.tags {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Assets returns the assets attached to a release
+func (fake *Fake) Assets(owner string, repo string, releaseID int64) []*github.ReleaseAsset {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	if repository := fake.repository(owner, repo); repository != nil {
+		return append([]*github.ReleaseAsset{}, repository.assets[releaseID]...)
+	}
+	return nil
+}
+
+// IterateReleases returns an iterator for the releases of a repository (newest to oldest)
+func (fake *Fake) IterateReleases(ctx context.Context, owner string, repository string, options ghapi.ReleaseIteratorOptions) *ghapi.ReleaseIterator {
+	return ghapi.NewReleaseIterator(ctx, func(ctx context.Context, page int, perPage int) ([]*github.RepositoryRelease, int, error) {
+		releases, fault := fake.listReleases(owner, repository)
+		if fault != FaultNone {
+			return nil, 0, fault.err("GET", "repos/"+owner+"/"+repository+"/releases")
+		}
+		start, end, nextPage := paginate(len(releases), page, perPage)
+		return releases[start:end], nextPage, nil
+	}, options)
+}
+
+// GetReleases returns all releases of a repository (newest to oldest)
+func (fake *Fake) GetReleases(ctx context.Context, owner string, repository string) ([]*github.RepositoryRelease, error) {
+	releases := make([]*github.RepositoryRelease, 0)
+	iterator := fake.IterateReleases(ctx, owner, repository, ghapi.ReleaseIteratorOptions{})
+	for iterator.Next() {
+		releases = append(releases, iterator.Release())
+	}
+	return releases, iterator.Err()
+}
+
+// RemoveRelease deletes a release and its tag
+func (fake *Fake) RemoveRelease(ctx context.Context, owner string, repo string, release *github.RepositoryRelease) error {
+	if err := fake.RemoveReleaseOnly(ctx, owner, repo, release); err != nil {
+		return err
+	}
+	if err := fake.DeleteTag(ctx, owner, repo, release.GetTagName()); err != nil {
+		return fmt.Errorf("release was deleted but its tag %q was not: %w", release.GetTagName(), err)
+	}
+	return nil
+}
+
+// RemoveReleaseOnly deletes a release, but keeps its tag (a release that is already gone counts as deleted)
+func (fake *Fake) RemoveReleaseOnly(ctx context.Context, owner string, repo string, release *github.RepositoryRelease) error {
+	if fault := fake.deleteRelease(owner, repo, release.GetID()); fault != FaultNone && fault != FaultNotFound {
+		return fault.err("DELETE", "repos/"+owner+"/"+repo+"/releases/"+strconv.FormatInt(release.GetID(), 10))
+	}
+	return nil
+}
+
+// GetTagSHA returns the SHA the tag points to
+func (fake *Fake) GetTagSHA(ctx context.Context, owner string, repo string, tag string) (string, error) {
+	sha, fault := fake.getTag(owner, repo, tag)
+	if fault != FaultNone {
+		return "", fault.err("GET", "repos/"+owner+"/"+repo+"/git/refs/tags/"+tag)
+	}
+	return sha, nil
+}
+
+// DeleteTag deletes a tag (a tag that is already gone counts as deleted)
+func (fake *Fake) DeleteTag(ctx context.Context, owner string, repo string, tag string) error {
+	if fault := fake.deleteTag(owner, repo, tag); fault != FaultNone && fault != FaultNotFound {
+		return fault.err("DELETE", "repos/"+owner+"/"+repo+"/git/refs/tags/"+tag)
+	}
+	return nil
+}
+
+// ListReleaseAssets returns all assets attached to a release
+func (fake *Fake) ListReleaseAssets(ctx context.Context, owner string, repo string, releaseID int64) ([]*github.ReleaseAsset, error) {
+	assets, fault := fake.listAssets(owner, repo, releaseID)
+	if fault != FaultNone {
+		return nil, fault.err("GET", "repos/"+owner+"/"+repo+"/releases/"+strconv.FormatInt(releaseID, 10)+"/assets")
+	}
+	return assets, nil
+}
+
+// DeleteReleaseAsset deletes a release asset (an asset that is already gone counts as deleted)
+func (fake *Fake) DeleteReleaseAsset(ctx context.Context, owner string, repo string, assetID int64) error {
+	if fault := fake.deleteAsset(owner, repo, assetID); fault != FaultNone && fault != FaultNotFound {
+		return fault.err("DELETE", "repos/"+owner+"/"+repo+"/releases/assets/"+strconv.FormatInt(assetID, 10))
+	}
+	return nil
+}
+
+// DiscoverRepositories lists all repositories of the selected organization or user that match the selector
+func (fake *Fake) DiscoverRepositories(ctx context.Context, selector ghapi.RepositorySelector) ([]*github.Repository, error) {
+	if (selector.Org == "") == (selector.User == "") {
+		return nil, errors.New("exactly one of organization or user must be selected")
+	}
+	owner := selector.Org + selector.User
+	repositories, fault := fake.listRepositories(owner)
+	if fault != FaultNone {
+		return nil, fault.err("GET", "users/"+owner+"/repos")
+	}
+	matched := make([]*github.Repository, 0)
+	for _, repository := range repositories {
+		if selector.Matches(repository) {
+			matched = append(matched, repository)
+		}
+	}
+	return matched, nil
+}
+
+// GetFileContents returns the contents of a file in the repository's default branch, or false if it doesn't exist
+func (fake *Fake) GetFileContents(ctx context.Context, owner string, repo string, path string) ([]byte, bool, error) {
+	content, fault := fake.getContents(owner, repo, path)
+	switch fault {
+	case FaultNone:
+		return content, true, nil
+	case FaultNotFound:
+		return nil, false, nil
+	}
+	return nil, false, fault.err("GET", "repos/"+owner+"/"+repo+"/contents/"+path)
+}
+
+// Identity returns who the fake authenticates as
+func (fake *Fake) Identity(ctx context.Context) (*ghapi.Identity, error) {
+	login, scopes, fault := fake.getUser()
+	if fault != FaultNone {
+		return nil, fault.err("GET", "user")
+	}
+	identity := &ghapi.Identity{Login: login, TokenType: ghapi.TokenTypeFineGrained}
+	if scopes != nil {
+		identity.TokenType = ghapi.TokenTypeClassic
+		identity.Scopes = scopes
+	}
+	return identity, nil
+}
+
+// Preflight verifies that the fake's user is allowed to delete releases from a repository
+func (fake *Fake) Preflight(ctx context.Context, owner string, repo string) (*ghapi.Identity, error) {
+	identity, err := fake.Identity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	repository, fault := fake.getRepository(owner, repo)
+	if fault == FaultNotFound {
+		return identity, fmt.Errorf("repository %s/%s does not exist or the token has no access to it: %w", owner, repo, fault.err("GET", "repos/"+owner+"/"+repo))
+	} else if fault != FaultNone {
+		return identity, fault.err("GET", "repos/"+owner+"/"+repo)
+	}
+	if identity.Scopes != nil && !identity.HasScope("repo") && (repository.GetPrivate() || !identity.HasScope("public_repo")) {
+		return identity, fmt.Errorf("the token is missing the \"repo\" scope required to delete releases from %s/%s: %w", owner, repo, ghapi.ErrForbidden)
+	}
+	if permissions := repository.GetPermissions(); !permissions["push"] && !permissions["admin"] {
+		return identity, fmt.Errorf("user %s has no push or admin permission on %s/%s, which is required to delete releases: %w", identity.Login, owner, repo, ghapi.ErrForbidden)
+	}
+	return identity, nil
+}
+
+// RateLimits returns the rate limits of the fake
+func (fake *Fake) RateLimits(ctx context.Context) ([]ghapi.RateLimit, error) {
+	limits, fault := fake.getRateLimits()
+	if fault != FaultNone {
+		return nil, fault.err("GET", "rate_limit")
+	}
+	return limits, nil
+}
+
+// The operations below are shared by the fake and the stand-in server, returning the fault to report instead of an error
+
+func (fake *Fake) getUser() (string, []string, Fault) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	if fault := fake.takeFault(OpGetUser); fault != FaultNone {
+		return "", nil, fault
+	}
+	return fake.Login, fake.Scopes, FaultNone
+}
+
+func (fake *Fake) getRateLimits() ([]ghapi.RateLimit, Fault) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	if fault := fake.takeFault(OpGetRateLimits); fault != FaultNone {
+		return nil, fault
+	}
+	return append([]ghapi.RateLimit{}, fake.Limits...), FaultNone
+}
+
+func (fake *Fake) getRepository(owner string, repo string) (*github.Repository, Fault) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	repository, fault := fake.lookup(OpGetRepository, owner, repo)
+	if fault != FaultNone {
+		return nil, fault
+	}
+	return repository.repository, FaultNone
+}
+
+func (fake *Fake) listRepositories(owner string) ([]*github.Repository, Fault) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	if fault := fake.takeFault(OpListRepositories); fault != FaultNone {
+		return nil, fault
+	}
+	repositories := make([]*github.Repository, 0)
+	for _, repository := range fake.repositories {
+		if strings.EqualFold(repository.repository.GetOwner().GetLogin(), owner) {
+			repositories = append(repositories, repository.repository)
+		}
+	}
+	sort.Slice(repositories, func(i, j int) bool {
+		return repositories[i].GetName() < repositories[j].GetName()
+	})
+	return repositories, FaultNone
+}
+
+func (fake *Fake) getContents(owner string, repo string, path string) ([]byte, Fault) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	repository, fault := fake.lookup(OpGetContents, owner, repo)
+	if fault != FaultNone {
+		return nil, fault
+	}
+	content, ok := repository.files[path]
+	if !ok {
+		return nil, FaultNotFound
+	}
+	return content, FaultNone
+}
+
+func (fake *Fake) listReleases(owner string, repo string) ([]*github.RepositoryRelease, Fault) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	repository, fault := fake.lookup(OpListReleases, owner, repo)
+	if fault != FaultNone {
+		return nil, fault
+	}
+	return sortedReleases(repository.releases), FaultNone
+}
+
+func (fake *Fake) deleteRelease(owner string, repo string, releaseID int64) Fault {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	repository, fault := fake.lookup(OpDeleteRelease, owner, repo)
+	if fault != FaultNone {
+		return fault
+	}
+	for index, release := range repository.releases {
+		if release.GetID() == releaseID {
+			repository.releases = append(repository.releases[:index], repository.releases[index+1:]...)
+			delete(repository.assets, releaseID)
+			return FaultNone
+		}
+	}
+	return FaultNotFound
+}
+
+func (fake *Fake) getTag(owner string, repo string, tag string) (string, Fault) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	repository, fault := fake.lookup(OpGetTag, owner, repo)
+	if fault != FaultNone {
+		return "", fault
+	}
+	sha, ok := repository.tags[tag]
+	if !ok {
+		return "", FaultNotFound
+	}
+	return sha, FaultNone
+}
+
+func (fake *Fake) deleteTag(owner string, repo string, tag string) Fault {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	repository, fault := fake.lookup(OpDeleteTag, owner, repo)
+	if fault != FaultNone {
+		return fault
+	}
+	if _, ok := repository.tags[tag]; !ok {
+		return FaultNotFound
+	}
+	delete(repository.tags, tag)
+	return FaultNone
+}
+
+func (fake *Fake) listAssets(owner string, repo string, releaseID int64) ([]*github.ReleaseAsset, Fault) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	repository, fault := fake.lookup(OpListAssets, owner, repo)
+	if fault != FaultNone {
+		return nil, fault
+	}
+	return append([]*github.ReleaseAsset{}, repository.assets[releaseID]...), FaultNone
+}
+
+func (fake *Fake) deleteAsset(owner string, repo string, assetID int64) Fault {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	repository, fault := fake.lookup(OpDeleteAsset, owner, repo)
+	if fault != FaultNone {
+		return fault
+	}
+	for releaseID, assets := range repository.assets {
+		for index, asset := range assets {
+			if asset.GetID() == assetID {
+				repository.assets[releaseID] = append(assets[:index], assets[index+1:]...)
+				repository.syncReleaseAssets(releaseID)
+				return FaultNone
+			}
+		}
+	}
+	return FaultNotFound
+}
+
+// lookup returns the repository for an operation, or the fault to report (the mutex must be held)
+func (fake *Fake) lookup(operation Operation, owner string, repo string) (*fakeRepository, Fault) {
+	if fault := fake.takeFault(operation); fault != FaultNone {
+		return nil, fault
+	}
+	repository := fake.repository(owner, repo)
+	if repository == nil {
+		return nil, FaultNotFound
+	}
+	return repository, FaultNone
+}
+
+// takeFault returns the fault injected into an operation, counting down its remaining calls (the mutex must be held)
+func (fake *Fake) takeFault(operation Operation) Fault {
+	injected, ok := fake.faults[operation]
+	if !ok {
+		return FaultNone
+	}
+	if injected.remaining > 0 {
+		injected.remaining--
+		if injected.remaining == 0 {
+			delete(fake.faults, operation)
+		}
+	}
+	return injected.fault
+}
+
+// repository returns a repository by owner and name, or nil if it doesn't exist (the mutex must be held)
+func (fake *Fake) repository(owner string, repo string) *fakeRepository {
+	return fake.repositories[strings.ToLower(owner+"/"+repo)]
+}
+
+// addRepository returns a repository, adding it if it doesn't exist yet (the mutex must be held)
+func (fake *Fake) addRepository(owner string, name string) *fakeRepository {
+	if repository := fake.repository(owner, name); repository != nil {
+		return repository
+	}
+	repository := &fakeRepository{
+		repository: &github.Repository{
+			ID:            github.Int64(fake.newID()),
+			Owner:         &github.User{Login: github.String(owner)},
+			Name:          github.String(name),
+			FullName:      github.String(owner + "/" + name),
+			DefaultBranch: github.String("main"),
+			Permissions:   &map[string]bool{"admin": true, "push": true, "pull": true},
+		},
+		tags:   make(map[string]string),
+		assets: make(map[int64][]*github.ReleaseAsset),
+		files:  make(map[string][]byte),
+	}
+	fake.repositories[strings.ToLower(owner+"/"+name)] = repository
+	return repository
+}
+
+// syncReleaseAssets updates the assets listed in a release to the ones attached to it (the mutex must be held)
+func (repository *fakeRepository) syncReleaseAssets(releaseID int64) {
+	for _, release := range repository.releases {
+		if release.GetID() == releaseID {
+			release.Assets = make([]github.ReleaseAsset, 0, len(repository.assets[releaseID]))
+			for _, asset := range repository.assets[releaseID] {
+				release.Assets = append(release.Assets, *asset)
+			}
+		}
+	}
+}
+
+// tagSHA returns the fake commit SHA the tag of a release points to
+func tagSHA(releaseID int64) string {
+	return fmt.Sprintf("%040x", releaseID)
+}
+
+// newID returns a new unique ID (the mutex must be held)
+func (fake *Fake) newID() int64 {
+	fake.nextID++
+	return fake.nextID
+}
+
+// sortedReleases returns a copy of the releases sorted newest to oldest, like the GitHub API does
+func sortedReleases(releases []*github.RepositoryRelease) []*github.RepositoryRelease {
+	sorted := append([]*github.RepositoryRelease{}, releases...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].GetCreatedAt().Time.After(sorted[j].GetCreatedAt().Time)
+	})
+	return sorted
+}
+
+// paginate returns the bounds of a page (starting at 1) of a list, along with the number of the next page (0 if there is none)
+func paginate(total int, page int, perPage int) (int, int, int) {
+	start := (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end := start + perPage
+	if end >= total {
+		return start, total, 0
+	}
+	return start, end, page + 1
+}
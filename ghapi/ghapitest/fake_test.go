@@ -0,0 +1,95 @@
+package ghapitest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Didstopia/githubby/ghapi"
+)
+
+func TestFakeReleases(t *testing.T) {
+	fake := NewFake()
+	now := time.Now()
+	oldest := fake.AddRelease("acme", "app", "v1", now.Add(-2*time.Hour))
+	fake.AddRelease("acme", "app", "v3", now)
+	fake.AddRelease("acme", "app", "v2", now.Add(-time.Hour))
+	fake.AddAsset("acme", "app", oldest.GetID(), "app.tar.gz")
+	if len(oldest.Assets) != 1 || oldest.Assets[0].GetName() != "app.tar.gz" {
+		t.Errorf("expected the asset to be listed in the release, got %v", oldest.Assets)
+	}
+	if sha, err := fake.GetTagSHA(context.Background(), "acme", "app", "v1"); err != nil || len(sha) != 40 {
+		t.Errorf("expected a tag SHA, got %q (%v)", sha, err)
+	}
+
+	// Releases are listed newest to oldest, page by page
+	iterator := fake.IterateReleases(context.Background(), "acme", "app", ghapi.ReleaseIteratorOptions{PerPage: 2})
+	tags := make([]string, 0)
+	for iterator.Next() {
+		tags = append(tags, iterator.Release().GetTagName())
+	}
+	if iterator.Err() != nil || strings.Join(tags, ",") != "v3,v2,v1" {
+		t.Errorf("expected v3,v2,v1, got %v (%v)", tags, iterator.Err())
+	}
+
+	// Removing a release also removes its tag and assets, unless the tag is kept
+	if err := fake.RemoveRelease(context.Background(), "acme", "app", oldest); err != nil {
+		t.Fatal(err)
+	}
+	releases := fake.Releases("acme", "app")
+	if err := fake.RemoveReleaseOnly(context.Background(), "acme", "app", releases[1]); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.Releases("acme", "app")) != 1 || strings.Join(fake.Tags("acme", "app"), ",") != "v2,v3" || len(fake.Assets("acme", "app", oldest.GetID())) != 0 {
+		t.Errorf("unexpected state: releases %v, tags %v", fake.Releases("acme", "app"), fake.Tags("acme", "app"))
+	}
+
+	// Removing a release that is already gone succeeds
+	if err := fake.RemoveRelease(context.Background(), "acme", "app", oldest); err != nil {
+		t.Errorf("expected removing a missing release to succeed, got %v", err)
+	}
+}
+
+func TestFakeFaults(t *testing.T) {
+	fake := NewFake()
+	release := fake.AddRelease("acme", "app", "v1", time.Now())
+
+	// A fault injected once only fails the next call
+	fake.Fail(OpDeleteTag, FaultForbidden, 1)
+	if err := fake.RemoveRelease(context.Background(), "acme", "app", release); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected a 403 error for the tag, got %v", err)
+	}
+	if err := fake.DeleteTag(context.Background(), "acme", "app", "v1"); err != nil {
+		t.Errorf("expected the fault to be used up, got %v", err)
+	}
+
+	// A fault injected without a limit fails every call
+	fake.Fail(OpListReleases, FaultRateLimited, 0)
+	for attempt := 0; attempt < 2; attempt++ {
+		if _, err := fake.GetReleases(context.Background(), "acme", "app"); err == nil {
+			t.Error("expected a rate limit error")
+		}
+	}
+
+	// Missing repositories aren't found
+	if _, err := fake.Preflight(context.Background(), "acme", "missing"); err == nil {
+		t.Error("expected an error for a missing repository")
+	}
+}
+
+func TestFakeDiscoverRepositories(t *testing.T) {
+	fake := NewFake()
+	fake.AddRepository("acme", "svc-api")
+	fake.AddRepository("acme", "svc-web").Archived = &[]bool{true}[0]
+	fake.AddRepository("acme", "docs")
+	fake.AddRepository("other", "svc-other")
+
+	repositories, err := fake.DiscoverRepositories(context.Background(), ghapi.RepositorySelector{Org: "acme", Names: []string{"svc-*"}, ExcludeArchived: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repositories) != 1 || repositories[0].GetFullName() != "acme/svc-api" {
+		t.Errorf("expected only acme/svc-api, got %v", repositories)
+	}
+}
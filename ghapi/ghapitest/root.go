@@ -0,0 +1,116 @@
+// Package ghapitest provides an in-memory GitHub backend and a stand-in GitHub API server,
+// for testing code built on the ghapi package without network access.
+package ghapitest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Didstopia/githubby/ghapi"
+	"github.com/google/go-github/v24/github"
+)
+
+// Operation identifies a GitHub API operation that faults can be injected into
+type Operation string
+
+// The operations supported by the fake and the stand-in server
+const (
+	OpGetUser          Operation = "get-user"
+	OpGetRateLimits    Operation = "get-rate-limits"
+	OpGetRepository    Operation = "get-repository"
+	OpListRepositories Operation = "list-repositories"
+	OpGetContents      Operation = "get-contents"
+	OpListReleases     Operation = "list-releases"
+	OpDeleteRelease    Operation = "delete-release"
+	OpGetTag           Operation = "get-tag"
+	OpDeleteTag        Operation = "delete-tag"
+	OpListAssets       Operation = "list-assets"
+	OpDeleteAsset      Operation = "delete-asset"
+)
+
+// Fault is a failure injected into an operation, reported the same way the GitHub API reports it
+type Fault int
+
+// The supported faults
+const (
+	// FaultNone means the operation succeeds
+	FaultNone Fault = iota
+
+	// FaultNotFound fails with "404 Not Found"
+	FaultNotFound
+
+	// FaultForbidden fails with "403 Forbidden"
+	FaultForbidden
+
+	// FaultRateLimited fails with "403 Forbidden" and an exhausted rate limit
+	FaultRateLimited
+
+	// FaultServerError fails with "500 Internal Server Error"
+	FaultServerError
+)
+
+// The time until an injected rate limit resets
+const rateLimitResetDelay = time.Hour
+
+// status returns the HTTP status code of the fault
+func (fault Fault) status() int {
+	switch fault {
+	case FaultNotFound:
+		return http.StatusNotFound
+	case FaultForbidden, FaultRateLimited:
+		return http.StatusForbidden
+	case FaultServerError:
+		return http.StatusInternalServerError
+	}
+	return http.StatusOK
+}
+
+// message returns the error message the GitHub API reports for the fault
+func (fault Fault) message() string {
+	switch fault {
+	case FaultNotFound:
+		return "Not Found"
+	case FaultForbidden:
+		return "Resource not accessible by integration"
+	case FaultRateLimited:
+		return "API rate limit exceeded for user."
+	case FaultServerError:
+		return "Server Error"
+	}
+	return ""
+}
+
+// header returns the response headers of the fault (the rate limit headers for an exhausted rate limit)
+func (fault Fault) header() http.Header {
+	header := http.Header{}
+	if fault == FaultRateLimited {
+		header.Set("X-RateLimit-Limit", "5000")
+		header.Set("X-RateLimit-Remaining", "0")
+		header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(rateLimitResetDelay).Unix(), 10))
+	}
+	return header
+}
+
+// err returns the error the ghapi package returns for the fault, as if the request was sent to the GitHub API
+func (fault Fault) err(method string, path string) error {
+	if fault == FaultNone {
+		return nil
+	}
+	req, _ := http.NewRequest(method, "https://api.github.com/"+path, nil)
+	res := &http.Response{StatusCode: fault.status(), Header: fault.header(), Request: req}
+	if fault == FaultRateLimited {
+		return ghapi.WrapError(&github.RateLimitError{
+			Rate:     github.Rate{Limit: 5000, Remaining: 0, Reset: github.Timestamp{Time: time.Now().Add(rateLimitResetDelay)}},
+			Response: res,
+			Message:  fault.message(),
+		})
+	}
+	return ghapi.WrapError(&github.ErrorResponse{Response: res, Message: fault.message()})
+}
+
+// injectedFault is a fault injected into an operation for a number of calls
+type injectedFault struct {
+	fault     Fault
+	remaining int
+}
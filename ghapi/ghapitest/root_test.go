@@ -0,0 +1,30 @@
+package ghapitest
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Didstopia/githubby/ghapi"
+	"github.com/google/go-github/v24/github"
+)
+
+func TestFaultErrors(t *testing.T) {
+	if err := FaultNone.err("GET", "user"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	var notFound *github.ErrorResponse
+	if err := FaultNotFound.err("GET", "user"); !errors.As(err, &notFound) || notFound.Response.StatusCode != http.StatusNotFound || !errors.Is(err, ghapi.ErrNotFound) {
+		t.Errorf("expected a 404 error response, got %v", err)
+	}
+
+	var rateLimited *github.RateLimitError
+	if err := FaultRateLimited.err("GET", "user"); !errors.As(err, &rateLimited) || rateLimited.Rate.Remaining != 0 || !errors.Is(err, ghapi.ErrRateLimited) {
+		t.Errorf("expected a rate limit error, got %v", err)
+	}
+
+	if err := FaultForbidden.err("DELETE", "repos/acme/app/releases/1"); !errors.Is(err, ghapi.ErrForbidden) {
+		t.Errorf("expected a forbidden error, got %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package ghapitest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Didstopia/githubby/ghapi"
+	"github.com/google/go-github/v24/github"
+)
+
+// The path prefix of the REST API on GitHub Enterprise Server, which the server mimics
+const apiPrefix = "/api/v3"
+
+// Server is a stand-in GitHub API server backed by a Fake, so real clients can be tested end to end without network access
+type Server struct {
+	*httptest.Server
+
+	// Fake holds the state served by the server (faults injected into it are served as API errors)
+	Fake *Fake
+
+	// BaseURL is the API URL to point clients at (eg. with ghapi.WithEnterpriseURLs or --base-url)
+	BaseURL string
+}
+
+// NewServer starts a stand-in GitHub API server for the fake, which must be closed when done
+func NewServer(fake *Fake) *Server {
+	server := &Server{Fake: fake}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	server.BaseURL = server.URL + apiPrefix + "/"
+	return server
+}
+
+// NewGitHub creates a ghapi client talking to the server
+func (server *Server) NewGitHub(opts ...ghapi.Option) (*ghapi.GitHub, error) {
+	return ghapi.NewGitHub("token", append([]ghapi.Option{ghapi.WithEnterpriseURLs(server.BaseURL, "")}, opts...)...)
+}
+
+// serveHTTP routes the supported REST API endpoints to the fake
+func (server *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, apiPrefix+"/") {
+		writeFault(w, FaultNotFound)
+		return
+	}
+	segments := strings.Split(strings.TrimPrefix(r.URL.Path, apiPrefix+"/"), "/")
+	fake := server.Fake
+
+	switch {
+	case r.Method == "GET" && matchRoute(segments, "user"):
+		login, scopes, fault := fake.getUser()
+		if fault != FaultNone {
+			writeFault(w, fault)
+			return
+		}
+		if scopes != nil {
+			w.Header().Set("X-OAuth-Scopes", strings.Join(scopes, ", "))
+		}
+		writeJSON(w, &github.User{Login: github.String(login)})
+	case r.Method == "GET" && matchRoute(segments, "rate_limit"):
+		limits, fault := fake.getRateLimits()
+		if fault != FaultNone {
+			writeFault(w, fault)
+			return
+		}
+		resources := make(map[string]*github.Rate)
+		for _, limit := range limits {
+			resources[limit.Name] = &github.Rate{Limit: limit.Limit, Remaining: limit.Remaining, Reset: github.Timestamp{Time: limit.Reset}}
+		}
+		writeJSON(w, map[string]interface{}{"resources": resources})
+	case r.Method == "GET" && (matchRoute(segments, "orgs", "*", "repos") || matchRoute(segments, "users", "*", "repos")):
+		server.serveRepositories(w, r, segments[1])
+	case r.Method == "GET" && matchRoute(segments, "user", "repos"):
+		server.serveRepositories(w, r, fake.Login)
+	case r.Method == "GET" && matchRoute(segments, "repos", "*", "*"):
+		repository, fault := fake.getRepository(segments[1], segments[2])
+		if fault != FaultNone {
+			writeFault(w, fault)
+			return
+		}
+		writeJSON(w, repository)
+	case r.Method == "GET" && len(segments) > 4 && matchRoute(segments[:4], "repos", "*", "*", "contents"):
+		content, fault := fake.getContents(segments[1], segments[2], strings.Join(segments[4:], "/"))
+		if fault != FaultNone {
+			writeFault(w, fault)
+			return
+		}
+		writeJSON(w, &github.RepositoryContent{
+			Type:     github.String("file"),
+			Encoding: github.String("base64"),
+			Content:  github.String(base64.StdEncoding.EncodeToString(content)),
+		})
+	case r.Method == "GET" && matchRoute(segments, "repos", "*", "*", "releases"):
+		releases, fault := fake.listReleases(segments[1], segments[2])
+		if fault != FaultNone {
+			writeFault(w, fault)
+			return
+		}
+		start, end := writePageLinks(w, r, len(releases))
+		writeJSON(w, releases[start:end])
+	case r.Method == "DELETE" && matchRoute(segments, "repos", "*", "*", "releases", "assets", "*"):
+		writeFault(w, fake.deleteAsset(segments[1], segments[2], parseID(segments[5])))
+	case r.Method == "DELETE" && matchRoute(segments, "repos", "*", "*", "releases", "*"):
+		writeFault(w, fake.deleteRelease(segments[1], segments[2], parseID(segments[4])))
+	case r.Method == "GET" && matchRoute(segments, "repos", "*", "*", "releases", "*", "assets"):
+		assets, fault := fake.listAssets(segments[1], segments[2], parseID(segments[4]))
+		if fault != FaultNone {
+			writeFault(w, fault)
+			return
+		}
+		start, end := writePageLinks(w, r, len(assets))
+		writeJSON(w, assets[start:end])
+	case r.Method == "GET" && len(segments) > 6 && matchRoute(segments[:6], "repos", "*", "*", "git", "refs", "tags"):
+		tag := strings.Join(segments[6:], "/")
+		sha, fault := fake.getTag(segments[1], segments[2], tag)
+		if fault != FaultNone {
+			writeFault(w, fault)
+			return
+		}
+		writeJSON(w, &github.Reference{
+			Ref:    github.String("refs/tags/" + tag),
+			Object: &github.GitObject{Type: github.String("commit"), SHA: github.String(sha)},
+		})
+	case r.Method == "DELETE" && len(segments) > 6 && matchRoute(segments[:6], "repos", "*", "*", "git", "refs", "tags"):
+		writeFault(w, fake.deleteTag(segments[1], segments[2], strings.Join(segments[6:], "/")))
+	default:
+		writeFault(w, FaultNotFound)
+	}
+}
+
+// serveRepositories lists the repositories of an owner
+func (server *Server) serveRepositories(w http.ResponseWriter, r *http.Request, owner string) {
+	repositories, fault := server.Fake.listRepositories(owner)
+	if fault != FaultNone {
+		writeFault(w, fault)
+		return
+	}
+	start, end := writePageLinks(w, r, len(repositories))
+	writeJSON(w, repositories[start:end])
+}
+
+// matchRoute checks if the path segments match a route, where "*" matches any single segment
+func matchRoute(segments []string, route ...string) bool {
+	if len(segments) != len(route) {
+		return false
+	}
+	for index, segment := range route {
+		if segment != "*" && segment != segments[index] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseID parses a numeric ID from the path (0 if it's invalid, which never matches anything)
+func parseID(segment string) int64 {
+	id, _ := strconv.ParseInt(segment, 10, 64)
+	return id
+}
+
+// writePageLinks adds the pagination links for the requested page of a list, returning the bounds of the page
+func writePageLinks(w http.ResponseWriter, r *http.Request, total int) (int, int) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = 30
+	}
+
+	start, end, nextPage := paginate(total, page, perPage)
+	if nextPage > 0 {
+		nextURL := url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path}
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(nextPage))
+		nextURL.RawQuery = query.Encode()
+		w.Header().Set("Link", "<"+nextURL.String()+">; rel=\"next\"")
+	}
+	return start, end
+}
+
+// writeJSON writes a successful JSON response
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeFault writes the response for a fault (204 No Content for FaultNone, as returned by deletions)
+func writeFault(w http.ResponseWriter, fault Fault) {
+	if fault == FaultNone {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	for key, values := range fault.header() {
+		w.Header()[key] = values
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(fault.status())
+	json.NewEncoder(w).Encode(map[string]string{"message": fault.message()})
+}
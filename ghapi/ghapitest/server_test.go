@@ -0,0 +1,75 @@
+package ghapitest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Didstopia/githubby/ghapi"
+	"github.com/google/go-github/v24/github"
+)
+
+func TestServer(t *testing.T) {
+	fake := NewFake()
+	now := time.Now()
+	for index := 0; index < 150; index++ {
+		fake.AddRelease("acme", "app", fmt.Sprintf("v1.0.%d", index), now.Add(-time.Duration(index)*time.Hour))
+	}
+	fake.SetFile("acme", "app", ".github/githubby.yml", []byte("filter-count: 10\n"))
+	server := NewServer(fake)
+	defer server.Close()
+
+	client, err := server.NewGitHub(ghapi.WithRetryPolicy(ghapi.RetryPolicy{MaxAttempts: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// The real client pages through all releases
+	releases, err := client.GetReleases(ctx, "acme", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(releases) != 150 {
+		t.Errorf("expected 150 releases, got %d", len(releases))
+	}
+
+	// Tags are looked up from the fake
+	if sha, err := client.GetTagSHA(ctx, "acme", "app", releases[0].GetTagName()); err != nil || sha != tagSHA(releases[0].GetID()) {
+		t.Errorf("unexpected tag SHA %q (%v)", sha, err)
+	}
+
+	// Deletions change the fake's state
+	if err := client.RemoveRelease(ctx, "acme", "app", releases[0]); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.Releases("acme", "app")) != 149 || len(fake.Tags("acme", "app")) != 149 {
+		t.Errorf("expected the release and its tag to be deleted")
+	}
+	if _, err := client.GetTagSHA(ctx, "acme", "app", releases[0].GetTagName()); !errors.Is(err, ghapi.ErrNotFound) {
+		t.Errorf("expected a deleted tag not to be found, got %v", err)
+	}
+
+	// Preflight and file contents are served from the fake
+	if identity, err := client.Preflight(ctx, "acme", "app"); err != nil || identity.Login != "octocat" || !identity.HasScope("repo") {
+		t.Errorf("unexpected preflight result %+v (%v)", identity, err)
+	}
+	if content, found, err := client.GetFileContents(ctx, "acme", "app", ".github/githubby.yml"); err != nil || !found || string(content) != "filter-count: 10\n" {
+		t.Errorf("unexpected file contents %q (found: %v, error: %v)", content, found, err)
+	}
+
+	// Injected faults are served as API errors
+	fake.Fail(OpDeleteRelease, FaultForbidden, 1)
+	if err := client.RemoveRelease(ctx, "acme", "app", releases[1]); !errors.Is(err, ghapi.ErrForbidden) || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected a 403 error, got %v", err)
+	}
+	fake.Fail(OpListReleases, FaultRateLimited, 1)
+	if _, err := client.GetReleases(ctx, "acme", "app"); err == nil {
+		t.Error("expected a rate limit error")
+	} else if rateLimitErr := (*github.RateLimitError)(nil); !errors.As(err, &rateLimitErr) || !errors.Is(err, ghapi.ErrRateLimited) {
+		t.Errorf("expected a rate limit error, got %T: %v", err, err)
+	}
+}
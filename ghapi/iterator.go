@@ -0,0 +1,122 @@
+package ghapi
+
+import (
+	"context"
+
+	"github.com/google/go-github/v24/github"
+)
+
+// The maximum number of releases the GitHub API returns per page
+const releasesPerPage = 100
+
+// ReleaseIteratorOptions controls how a ReleaseIterator fetches releases
+type ReleaseIteratorOptions struct {
+	// Limit stops the iteration after this many releases (0 or less means no limit)
+	Limit int
+
+	// PerPage sets the page size used when fetching releases (defaults to the API maximum)
+	PerPage int
+}
+
+// ReleasePageFunc fetches a single page of releases (newest to oldest), returning the number of the next page (0 if there is none)
+type ReleasePageFunc func(ctx context.Context, page int, perPage int) ([]*github.RepositoryRelease, int, error)
+
+// ReleaseIterator lazily walks through the releases of a repository (newest to oldest),
+// only fetching the next page from the API once the current one has been consumed
+type ReleaseIterator struct {
+	fetch   ReleasePageFunc
+	ctx     context.Context
+	options ReleaseIteratorOptions
+
+	page    int
+	buffer  []*github.RepositoryRelease
+	current *github.RepositoryRelease
+	count   int
+	done    bool
+	err     error
+}
+
+// IterateReleases returns a new iterator for the releases of the supplied repository
+func (githubClient *GitHub) IterateReleases(ctx context.Context, owner string, repository string, options ReleaseIteratorOptions) *ReleaseIterator {
+	return NewReleaseIterator(ctx, func(ctx context.Context, page int, perPage int) ([]*github.RepositoryRelease, int, error) {
+		//log.Println("Getting releases for page ", page)
+		releases, res, err := githubClient.client.Repositories.ListReleases(ctx, owner, repository, &github.ListOptions{Page: page, PerPage: perPage})
+		if err != nil {
+			return nil, 0, WrapError(err)
+		}
+		return releases, res.NextPage, nil
+	}, options)
+}
+
+// NewReleaseIterator returns a new iterator fetching the releases page by page with the supplied function,
+// which allows other backends to share the iteration logic
+func NewReleaseIterator(ctx context.Context, fetch ReleasePageFunc, options ReleaseIteratorOptions) *ReleaseIterator {
+	if options.PerPage <= 0 || options.PerPage > releasesPerPage {
+		options.PerPage = releasesPerPage
+	}
+	return &ReleaseIterator{
+		fetch:   fetch,
+		ctx:     ctx,
+		options: options,
+		page:    1,
+	}
+}
+
+// Next advances the iterator to the next release, returning false when there are
+// no releases left, the limit has been reached, the context was cancelled or an error occurred
+func (iterator *ReleaseIterator) Next() bool {
+	iterator.current = nil
+
+	// Stop early if the limit has been reached
+	if iterator.err != nil || (iterator.options.Limit > 0 && iterator.count >= iterator.options.Limit) {
+		return false
+	}
+
+	// Fetch the next page once the current one is exhausted
+	for len(iterator.buffer) == 0 {
+		if iterator.done {
+			return false
+		}
+		if err := iterator.ctx.Err(); err != nil {
+			iterator.err = err
+			return false
+		}
+		if err := iterator.fetchPage(); err != nil {
+			iterator.err = err
+			return false
+		}
+	}
+
+	// Move on to the next release
+	iterator.current = iterator.buffer[0]
+	iterator.buffer = iterator.buffer[1:]
+	iterator.count++
+	return true
+}
+
+// Release returns the release the iterator currently points at
+func (iterator *ReleaseIterator) Release() *github.RepositoryRelease {
+	return iterator.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (iterator *ReleaseIterator) Err() error {
+	return iterator.err
+}
+
+func (iterator *ReleaseIterator) fetchPage() error {
+	// Get releases for the current page
+	releases, nextPage, err := iterator.fetch(iterator.ctx, iterator.page, iterator.options.PerPage)
+	if err != nil {
+		return err
+	}
+	iterator.buffer = releases
+
+	// Move to the next page if there are any more pages left
+	if nextPage > 0 && nextPage > iterator.page {
+		iterator.page = nextPage
+	} else {
+		iterator.done = true
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package ghapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+// newPagedReleaseServer serves the supplied number of releases in pages of two, counting the requested pages
+func newPagedReleaseServer(t *testing.T, total int, requests *int) (*GitHub, *httptest.Server) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 1 {
+			page = 1
+		}
+		start := (page - 1) * 2
+		if start+2 < total {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=%d>; rel="next"`, "http://"+r.Host, r.URL.Path, page+1))
+		}
+		body := "["
+		for id := start; id < start+2 && id < total; id++ {
+			if id > start {
+				body += ","
+			}
+			body += fmt.Sprintf(`{"id":%d}`, id)
+		}
+		fmt.Fprint(w, body+"]")
+	}))
+
+	client, err := NewGitHub("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client, server
+}
+
+func TestReleaseIteratorWalksAllPages(t *testing.T) {
+	requests := 0
+	client, server := newPagedReleaseServer(t, 5, &requests)
+	defer server.Close()
+
+	iterator := client.IterateReleases(context.Background(), "owner", "repo", ReleaseIteratorOptions{PerPage: 2})
+	count := 0
+	for iterator.Next() {
+		if iterator.Release().GetID() != int64(count) {
+			t.Errorf("expected release %d, got %d", count, iterator.Release().GetID())
+		}
+		count++
+	}
+	if err := iterator.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 5 {
+		t.Errorf("expected 5 releases, got %d", count)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 page requests, got %d", requests)
+	}
+}
+
+func TestReleaseIteratorStopsAtLimit(t *testing.T) {
+	requests := 0
+	client, server := newPagedReleaseServer(t, 10, &requests)
+	defer server.Close()
+
+	iterator := client.IterateReleases(context.Background(), "owner", "repo", ReleaseIteratorOptions{PerPage: 2, Limit: 3})
+	count := 0
+	for iterator.Next() {
+		count++
+	}
+	if err := iterator.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 3 {
+		t.Errorf("expected 3 releases, got %d", count)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 page requests, got %d", requests)
+	}
+}
+
+func TestReleaseIteratorHonorsCancellation(t *testing.T) {
+	requests := 0
+	client, server := newPagedReleaseServer(t, 10, &requests)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	iterator := client.IterateReleases(ctx, "owner", "repo", ReleaseIteratorOptions{})
+	if iterator.Next() {
+		t.Error("expected a cancelled iterator to yield no releases")
+	}
+	if iterator.Err() != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, iterator.Err())
+	}
+	if requests != 0 {
+		t.Errorf("expected no page requests, got %d", requests)
+	}
+}
@@ -0,0 +1,158 @@
+package ghapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Token types, as detected from the token prefix
+const (
+	TokenTypeClassic      = "personal access token (classic)"
+	TokenTypeFineGrained  = "fine-grained personal access token"
+	TokenTypeOAuth        = "OAuth token"
+	TokenTypeUserToServer = "GitHub App user token"
+	TokenTypeInstallation = "GitHub App installation token"
+	TokenTypeUnknown      = "unknown"
+)
+
+// The layout of the token expiration header (eg. 2023-04-08 07:00:00 UTC)
+const tokenExpirationLayout = "2006-01-02 15:04:05 MST"
+
+// Identity describes who the client authenticates as
+type Identity struct {
+	// Login is the user the token belongs to (empty for GitHub App installations)
+	Login string
+
+	// TokenType is the kind of token in use (see the TokenType constants)
+	TokenType string
+
+	// Scopes are the OAuth scopes of the token, or nil if the token doesn't report
+	// any (fine-grained and GitHub App tokens use permissions instead)
+	Scopes []string
+
+	// Expiry is when the token expires, or nil if it doesn't expire (or doesn't report it)
+	Expiry *time.Time
+}
+
+// HasScope checks if the identity was granted the supplied OAuth scope
+func (identity *Identity) HasScope(scope string) bool {
+	for _, grantedScope := range identity.Scopes {
+		if grantedScope == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenType detects the type of a token from its prefix
+func tokenType(token string) string {
+	switch {
+	case strings.HasPrefix(token, "ghp_"):
+		return TokenTypeClassic
+	case strings.HasPrefix(token, "github_pat_"):
+		return TokenTypeFineGrained
+	case strings.HasPrefix(token, "gho_"):
+		return TokenTypeOAuth
+	case strings.HasPrefix(token, "ghu_"):
+		return TokenTypeUserToServer
+	case strings.HasPrefix(token, "ghs_"):
+		return TokenTypeInstallation
+	case len(token) == 40:
+		// Tokens created before the prefixes were introduced are 40 hex characters
+		return TokenTypeClassic
+	}
+	return TokenTypeUnknown
+}
+
+// Identity returns who the client authenticates as, along with the scopes of its token
+func (githubClient *GitHub) Identity(ctx context.Context) (*Identity, error) {
+	// GitHub App installations aren't users, so there's nothing to look up besides the token expiry
+	if githubClient.installation != nil {
+		if _, err := githubClient.tokenSource.Token(); err != nil {
+			return nil, err
+		}
+		expiry := githubClient.installation.expiry()
+		return &Identity{TokenType: TokenTypeInstallation, Expiry: &expiry}, nil
+	}
+
+	// Static installation tokens (eg. the GITHUB_TOKEN of GitHub Actions) can't read the user either,
+	// their permissions are checked per repository instead
+	if githubClient.tokenType == TokenTypeInstallation {
+		return &Identity{TokenType: TokenTypeInstallation}, nil
+	}
+
+	user, res, err := githubClient.client.Users.Get(ctx, "")
+	if err = WrapError(err); err != nil {
+		if errors.Is(err, ErrUnauthorized) {
+			return nil, &describedError{message: "the token is invalid or has expired", err: err}
+		}
+		return nil, err
+	}
+
+	identity := &Identity{
+		Login:     user.GetLogin(),
+		TokenType: githubClient.tokenType,
+	}
+
+	// Only classic and OAuth tokens report their scopes
+	if header, ok := res.Header["X-Oauth-Scopes"]; ok {
+		identity.Scopes = make([]string, 0)
+		for _, scope := range strings.Split(strings.Join(header, ","), ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				identity.Scopes = append(identity.Scopes, scope)
+			}
+		}
+	}
+
+	// Personal access tokens with an expiration date report it on every response
+	if header := res.Header.Get("GitHub-Authentication-Token-Expiration"); header != "" {
+		if expiry, err := time.Parse(tokenExpirationLayout, header); err == nil {
+			identity.Expiry = &expiry
+		}
+	}
+
+	return identity, nil
+}
+
+// Preflight verifies that the client is allowed to delete releases from the supplied repository,
+// returning the identity it authenticates as, so destructive commands can fail before doing any work
+func (githubClient *GitHub) Preflight(ctx context.Context, owner string, repo string) (*Identity, error) {
+	identity, err := githubClient.Identity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repository, _, err := githubClient.client.Repositories.Get(ctx, owner, repo)
+	if err = WrapError(err); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return identity, &describedError{message: fmt.Sprintf("repository %s/%s does not exist or the token has no access to it", owner, repo), err: err}
+		}
+		return identity, err
+	}
+
+	// Classic and OAuth tokens need the "repo" scope (or "public_repo" for public repositories)
+	if identity.Scopes != nil && !identity.HasScope("repo") && (repository.GetPrivate() || !identity.HasScope("public_repo")) {
+		return identity, &describedError{message: fmt.Sprintf("the token is missing the \"repo\" scope required to delete releases from %s/%s (granted scopes: %s)", owner, repo, strings.Join(identity.Scopes, ", ")), err: ErrForbidden}
+	}
+
+	// Deleting releases and tags requires push (or admin) permission
+	if repository.Permissions != nil {
+		permissions := *repository.Permissions
+		if !permissions["push"] && !permissions["admin"] {
+			return identity, &describedError{message: fmt.Sprintf("%s has no push or admin permission on %s/%s, which is required to delete releases", describeIdentity(identity), owner, repo), err: ErrForbidden}
+		}
+	}
+
+	return identity, nil
+}
+
+// describeIdentity returns a human readable description of an identity
+func describeIdentity(identity *Identity) string {
+	if identity.Login == "" {
+		return "the " + identity.TokenType
+	}
+	return "user " + identity.Login
+}
@@ -0,0 +1,156 @@
+package ghapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newPreflightServer serves a user with the supplied scopes header and a repository with the supplied permissions
+func newPreflightServer(t *testing.T, scopes *string, private bool, permissions string) (*GitHub, *httptest.Server) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/user":
+			if scopes != nil {
+				w.Header().Set("X-OAuth-Scopes", *scopes)
+			}
+			fmt.Fprint(w, `{"login":"someone"}`)
+		case "/api/v3/repos/owner/repo":
+			fmt.Fprintf(w, `{"private":%t,"permissions":%s}`, private, permissions)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	client, err := NewGitHub("ghp_token", WithEnterpriseURLs(server.URL+"/api/v3/", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, server
+}
+
+func TestPreflight(t *testing.T) {
+	repoScope := "repo, read:org"
+	publicRepoScope := "public_repo"
+	noScopes := ""
+
+	tests := []struct {
+		name        string
+		scopes      *string
+		private     bool
+		permissions string
+		errContains string
+	}{
+		{"classic token with repo scope", &repoScope, true, `{"push":true}`, ""},
+		{"classic token with public_repo scope on a public repository", &publicRepoScope, false, `{"push":true}`, ""},
+		{"classic token with public_repo scope on a private repository", &publicRepoScope, true, `{"push":true}`, "scope"},
+		{"classic token without scopes", &noScopes, false, `{"push":true}`, "scope"},
+		{"fine-grained token with push permission", nil, true, `{"push":true}`, ""},
+		{"fine-grained token with admin permission", nil, true, `{"admin":true}`, ""},
+		{"fine-grained token without push permission", nil, true, `{"pull":true}`, "no push or admin permission"},
+	}
+
+	for _, test := range tests {
+		client, server := newPreflightServer(t, test.scopes, test.private, test.permissions)
+		identity, err := client.Preflight(context.Background(), "owner", "repo")
+		server.Close()
+
+		if test.errContains == "" && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		} else if test.errContains != "" && (err == nil || !strings.Contains(err.Error(), test.errContains)) {
+			t.Errorf("%s: expected an error containing %q, got %v", test.name, test.errContains, err)
+		}
+		if identity == nil || identity.Login != "someone" {
+			t.Errorf("%s: expected identity someone, got %+v", test.name, identity)
+		}
+	}
+}
+
+func TestPreflightMissingRepository(t *testing.T) {
+	client, server := newPreflightServer(t, nil, false, `{}`)
+	defer server.Close()
+
+	if _, err := client.Preflight(context.Background(), "owner", "missing"); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected a missing repository error, got %v", err)
+	}
+}
+
+func TestPreflightInstallationToken(t *testing.T) {
+	permissions := `{"push":true}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/user":
+			// Installation tokens aren't allowed to read the user
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message":"Resource not accessible by integration"}`)
+		case "/api/v3/repos/owner/repo":
+			fmt.Fprintf(w, `{"private":true,"permissions":%s}`, permissions)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewGitHub("ghs_token", WithEnterpriseURLs(server.URL+"/api/v3/", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := client.Preflight(context.Background(), "owner", "repo")
+	if err != nil {
+		t.Fatalf("expected an installation token with push permission to pass, got %v", err)
+	}
+	if identity.TokenType != TokenTypeInstallation || identity.Login != "" || identity.Scopes != nil {
+		t.Errorf("unexpected identity %+v", identity)
+	}
+
+	permissions = `{"pull":true}`
+	if _, err := client.Preflight(context.Background(), "owner", "repo"); err == nil || !strings.Contains(err.Error(), "the "+TokenTypeInstallation+" has no push or admin permission") {
+		t.Errorf("expected a missing permission error, got %v", err)
+	}
+}
+
+func TestTokenType(t *testing.T) {
+	tests := map[string]string{
+		"ghp_abc":        TokenTypeClassic,
+		"github_pat_abc": TokenTypeFineGrained,
+		"gho_abc":        TokenTypeOAuth,
+		"ghs_abc":        TokenTypeInstallation,
+		"0123456789abcdef0123456789abcdef01234567": TokenTypeClassic,
+		"something": TokenTypeUnknown,
+	}
+	for token, expected := range tests {
+		if actual := tokenType(token); actual != expected {
+			t.Errorf("%s: expected %s, got %s", token, expected, actual)
+		}
+	}
+}
+
+func TestIdentityReportsExpiry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("GitHub-Authentication-Token-Expiration", "2023-04-08 07:00:00 UTC")
+		fmt.Fprint(w, `{"login":"someone"}`)
+	}))
+	defer server.Close()
+
+	client, err := NewGitHub("github_pat_token", WithEnterpriseURLs(server.URL+"/api/v3/", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity, err := client.Identity(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Expiry == nil || identity.Expiry.Unix() != 1680937200 {
+		t.Errorf("expected expiry 2023-04-08 07:00:00 UTC, got %v", identity.Expiry)
+	}
+	if identity.Scopes != nil {
+		t.Errorf("expected no scopes for a fine-grained token, got %v", identity.Scopes)
+	}
+	if identity.TokenType != TokenTypeFineGrained {
+		t.Errorf("expected token type %s, got %s", TokenTypeFineGrained, identity.TokenType)
+	}
+}
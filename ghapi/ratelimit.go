@@ -0,0 +1,70 @@
+package ghapi
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v24/github"
+)
+
+// The order rate limit buckets are reported in (any others follow alphabetically)
+var rateLimitOrder = map[string]int{"core": 0, "search": 1, "graphql": 2}
+
+// RateLimit describes the state of a single rate limit bucket
+type RateLimit struct {
+	Name      string
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// RateLimits returns the state of every rate limit bucket (core, search, graphql, ..),
+// which doesn't count against the rate limits itself
+func (githubClient *GitHub) RateLimits(ctx context.Context) ([]RateLimit, error) {
+	// Request the rate limits directly, as the client library doesn't know about every bucket
+	req, err := githubClient.client.NewRequest("GET", "rate_limit", nil)
+	if err != nil {
+		return nil, err
+	}
+	response := struct {
+		Resources map[string]*github.Rate `json:"resources"`
+	}{}
+	if _, err := githubClient.client.Do(ctx, req, &response); err != nil {
+		// Rate limiting can be disabled on GitHub Enterprise Server
+		err = WrapError(err)
+		if errors.Is(err, ErrNotFound) {
+			return nil, &describedError{message: "rate limiting is not enabled on this GitHub instance", err: err}
+		}
+		return nil, err
+	}
+
+	rateLimits := make([]RateLimit, 0, len(response.Resources))
+	for name, rate := range response.Resources {
+		if rate == nil {
+			continue
+		}
+		rateLimits = append(rateLimits, RateLimit{
+			Name:      name,
+			Limit:     rate.Limit,
+			Remaining: rate.Remaining,
+			Reset:     rate.Reset.Time,
+		})
+	}
+
+	// Sort the well known buckets first
+	sort.Slice(rateLimits, func(i, j int) bool {
+		iOrder, iKnown := rateLimitOrder[rateLimits[i].Name]
+		jOrder, jKnown := rateLimitOrder[rateLimits[j].Name]
+		if iKnown && jKnown {
+			return iOrder < jOrder
+		}
+		if iKnown != jKnown {
+			return iKnown
+		}
+		return rateLimits[i].Name < rateLimits[j].Name
+	})
+
+	return rateLimits, nil
+}
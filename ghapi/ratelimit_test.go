@@ -0,0 +1,44 @@
+package ghapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"resources":{
+			"source_import":{"limit":100,"remaining":100,"reset":1500000000},
+			"graphql":{"limit":5000,"remaining":4999,"reset":1500000000},
+			"search":{"limit":30,"remaining":30,"reset":1500000000},
+			"core":{"limit":5000,"remaining":4000,"reset":1500000000}
+		}}`)
+	}))
+	defer server.Close()
+
+	client, err := NewGitHub("token", WithEnterpriseURLs(server.URL+"/api/v3/", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rateLimits, err := client.RateLimits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"core", "search", "graphql", "source_import"}
+	if len(rateLimits) != len(expected) {
+		t.Fatalf("expected %d buckets, got %d", len(expected), len(rateLimits))
+	}
+	for i, name := range expected {
+		if rateLimits[i].Name != name {
+			t.Errorf("expected bucket %d to be %s, got %s", i, name, rateLimits[i].Name)
+		}
+	}
+	if rateLimits[0].Remaining != 4000 || rateLimits[0].Reset.Unix() != 1500000000 {
+		t.Errorf("unexpected core bucket: %+v", rateLimits[0])
+	}
+}
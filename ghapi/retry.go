@@ -0,0 +1,142 @@
+package ghapi
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how transient API failures are retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per request (1 disables retrying)
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry, doubled on every subsequent retry
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between any two attempts
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is explicitly configured
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 1 * time.Second,
+		MaxBackoff:     30 * time.Second,
+	}
+}
+
+// backoff returns the jittered delay to wait before the supplied retry (starting at 1)
+func (policy RetryPolicy) backoff(retry int) time.Duration {
+	// Grow the delay exponentially, making sure we never exceed the maximum
+	delay := policy.InitialBackoff
+	for i := 1; i < retry && delay < policy.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if policy.MaxBackoff > 0 && delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	// Apply jitter so that concurrent clients don't retry in lockstep
+	half := int64(delay / 2)
+	jitterMutex.Lock()
+	defer jitterMutex.Unlock()
+	return time.Duration(half + jitterSource.Int63n(half+1))
+}
+
+// The random source used for jittering backoff delays
+var jitterSource = rand.New(rand.NewSource(time.Now().UnixNano()))
+var jitterMutex sync.Mutex
+
+// retryTransport is a http.RoundTripper that retries transient failures of idempotent requests
+// (others may have been carried out by the server, so retrying them could repeat their effect)
+type retryTransport struct {
+	base   http.RoundTripper
+	policy RetryPolicy
+}
+
+// RoundTrip executes a single HTTP transaction, retrying it according to the retry policy
+func (transport *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := transport.policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		// Requests with a body can only be retried if the body can be recreated
+		attemptReq := req
+		if attempt > 1 && req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, errors.New("unable to retry request with a non-replayable body")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		res, err := transport.base.RoundTrip(attemptReq)
+
+		// Return immediately if we're out of attempts, cancelled, the request isn't idempotent or the failure isn't transient
+		if attempt >= attempts || req.Context().Err() != nil || !isIdempotent(req.Method) || !isRetryable(res, err) {
+			return res, err
+		}
+
+		// Discard the failed response so the connection can be reused
+		if res != nil {
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		// Wait before the next attempt, unless the request is cancelled in the meantime
+		timer := time.NewTimer(transport.policy.backoff(attempt))
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isIdempotent checks if repeating a request has the same effect as sending it once
+func isIdempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isRetryable checks if a response or error is caused by a transient failure
+func isRetryable(res *http.Response, err error) bool {
+	if err != nil {
+		// Connection resets and unexpectedly closed connections
+		if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			return true
+		}
+
+		// Network timeouts
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return true
+		}
+
+		return false
+	}
+
+	// Server side errors
+	return res.StatusCode >= 500 && res.StatusCode <= 599
+}
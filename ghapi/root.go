@@ -3,6 +3,11 @@ package ghapi
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/google/go-github/v24/github"
 	"golang.org/x/oauth2"
@@ -10,32 +15,172 @@ import (
 
 // GitHub is an abstraction for the real GitHub API client
 type GitHub struct {
-	ctx    context.Context
-	client *github.Client
+	client       *github.Client
+	tokenSource  oauth2.TokenSource
+	installation *installationTokenSource
+	tokenType    string
+}
+
+// Option configures optional behaviour of a GitHub object
+type Option func(*options)
+
+// DefaultUserAgent is the User-Agent sent with API requests unless overridden with WithUserAgent
+const DefaultUserAgent = "githubby"
+
+// options holds the optional settings applied by NewGitHub
+type options struct {
+	retryPolicy RetryPolicy
+	baseURL     string
+	uploadURL   string
+	app         *AppCredentials
+	userAgent   string
+	transport   TransportConfig
+	trace       TraceFunc
+}
+
+// WithRetryPolicy overrides the policy used for retrying transient API failures
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(opts *options) {
+		opts.retryPolicy = policy
+	}
+}
+
+// WithEnterpriseURLs routes all requests to a GitHub Enterprise Server instance,
+// deriving the upload URL from the base URL if it's left empty
+func WithEnterpriseURLs(baseURL string, uploadURL string) Option {
+	return func(opts *options) {
+		opts.baseURL = baseURL
+		opts.uploadURL = uploadURL
+	}
+}
+
+// WithUserAgent overrides the User-Agent sent with every API request (eg. "githubby/1.2.3")
+func WithUserAgent(userAgent string) Option {
+	return func(opts *options) {
+		opts.userAgent = userAgent
+	}
 }
 
 // NewGitHub creates and returns a reference to a new GitHub object
-func NewGitHub(token string) (*GitHub, error) {
+func NewGitHub(token string, opts ...Option) (*GitHub, error) {
 	githubClient := &GitHub{}
 
-	// Create an authentication context for the GitHub API client
-	githubClient.ctx = context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	tc := oauth2.NewClient(githubClient.ctx, ts)
+	// Apply any options on top of the defaults
+	clientOptions := &options{
+		retryPolicy: DefaultRetryPolicy(),
+		userAgent:   DefaultUserAgent,
+	}
+	for _, opt := range opts {
+		opt(clientOptions)
+	}
+
+	// Create the HTTP transport shared by all requests
+	transport, err := clientOptions.newBaseTransport()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the token source, either from a static token or by authenticating as a GitHub App
+	var ts oauth2.TokenSource
+	if clientOptions.app != nil {
+		installation, err := clientOptions.newInstallationTokenSource(transport)
+		if err != nil {
+			return nil, err
+		}
+		githubClient.installation = installation
+
+		// Cache each installation token until shortly before it expires
+		ts = oauth2.ReuseTokenSource(nil, installation)
+	} else {
+		ts = oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: token},
+		)
+	}
+
+	// Create an authenticated HTTP client for the GitHub API client,
+	// retrying transient failures (including token retrieval) before giving up
+	tc := &http.Client{Transport: &retryTransport{
+		base:   &oauth2.Transport{Source: ts, Base: transport},
+		policy: clientOptions.retryPolicy,
+	}}
 
 	// Create the actual GitHub API client
-	githubClient.client = github.NewClient(tc)
+	client, err := clientOptions.newClient(tc)
+	if err != nil {
+		return nil, err
+	}
+	githubClient.client = client
+	githubClient.tokenSource = ts
+	githubClient.tokenType = tokenType(token)
 
 	return githubClient, nil
 }
 
+// newClient creates a GitHub API client with the configured User-Agent, using the enterprise endpoints if configured
+func (opts *options) newClient(httpClient *http.Client) (*github.Client, error) {
+	client := github.NewClient(httpClient)
+	if opts.baseURL != "" {
+		baseURL, err := enterpriseBaseURL(opts.baseURL)
+		if err != nil {
+			return nil, err
+		}
+		uploadURL := opts.uploadURL
+		if uploadURL == "" {
+			uploadURL = enterpriseUploadURL(baseURL)
+		}
+		client, err = github.NewEnterpriseClient(baseURL, uploadURL, httpClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+	client.UserAgent = opts.userAgent
+	return client, nil
+}
+
+// newBaseTransport creates the configured HTTP transport, tracing every request attempt if enabled
+func (opts *options) newBaseTransport() (http.RoundTripper, error) {
+	transport, err := opts.transport.newTransport()
+	if err != nil {
+		return nil, err
+	}
+	if opts.trace != nil {
+		return &traceTransport{base: transport, trace: opts.trace}, nil
+	}
+	return transport, nil
+}
+
+// newInstallationTokenSource creates a token source for the configured GitHub App, sending requests with the transport
+func (opts *options) newInstallationTokenSource(transport http.RoundTripper) (*installationTokenSource, error) {
+	key, err := parseAppPrivateKey(opts.app.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// The app itself authenticates with a JWT instead of a token
+	appClient, err := opts.newClient(&http.Client{Transport: &retryTransport{
+		base:   &appTransport{base: transport, appID: opts.app.AppID, key: key},
+		policy: opts.retryPolicy,
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	return &installationTokenSource{
+		appClient:      appClient,
+		owner:          opts.app.InstallationOwner,
+		installationID: opts.app.InstallationID,
+	}, nil
+}
+
 // GetReleases returns all release information for the supplied repository
-func (githubClient *GitHub) GetReleases(owner string, repository string) ([]*github.RepositoryRelease, error) {
+func (githubClient *GitHub) GetReleases(ctx context.Context, owner string, repository string) ([]*github.RepositoryRelease, error) {
 	// Find all releases (handles pagination behind the scenes, starting at page 1)
-	releases, err := githubClient.getAllReleases(owner, repository, 1, nil)
-	if err != nil {
+	releases := make([]*github.RepositoryRelease, 0)
+	iterator := githubClient.IterateReleases(ctx, owner, repository, ReleaseIteratorOptions{})
+	for iterator.Next() {
+		releases = append(releases, iterator.Release())
+	}
+	if err := iterator.Err(); err != nil {
 		return nil, err
 	}
 
@@ -44,97 +189,96 @@ func (githubClient *GitHub) GetReleases(owner string, repository string) ([]*git
 	return releases, nil
 }
 
-// RemoveRelease will attempt to delete a release from GitHub
-func (githubClient *GitHub) RemoveRelease(owner string, repo string, release *github.RepositoryRelease) error {
+// RemoveRelease will attempt to delete a release and its tag from GitHub
+func (githubClient *GitHub) RemoveRelease(ctx context.Context, owner string, repo string, release *github.RepositoryRelease) error {
 	// Delete the release
-	deleteReleaseErr := githubClient.deleteRelease(release)
+	deleteReleaseErr := githubClient.deleteRelease(ctx, owner, repo, release)
 	if deleteReleaseErr != nil {
 		return deleteReleaseErr
 	}
 
-	// Delete the tag
-	deleteTagErr := githubClient.deleteTag(owner, repo, release)
+	// Delete the tag, making it clear that the release itself is already gone if this fails
+	deleteTagErr := githubClient.DeleteTag(ctx, owner, repo, release.GetTagName())
 	if deleteTagErr != nil {
-		return deleteTagErr
+		return fmt.Errorf("release was deleted but its tag %q was not: %w", release.GetTagName(), deleteTagErr)
 	}
 
 	// Return nil on success
 	return nil
 }
 
-func (githubClient *GitHub) deleteRelease(release *github.RepositoryRelease) error {
-	//log.Println("Deleting release:", release.TagName)
+// RemoveReleaseOnly will attempt to delete a release from GitHub, while keeping its tag
+func (githubClient *GitHub) RemoveReleaseOnly(ctx context.Context, owner string, repo string, release *github.RepositoryRelease) error {
+	return githubClient.deleteRelease(ctx, owner, repo, release)
+}
 
-	// Create the release deletion request
-	req, reqErr := githubClient.client.NewRequest("DELETE", *release.URL, nil)
-	if reqErr != nil {
-		return reqErr
-	}
+func (githubClient *GitHub) deleteRelease(ctx context.Context, owner string, repo string, release *github.RepositoryRelease) error {
+	//log.Println("Deleting release:", release.TagName)
 
-	// Run the request
-	_, doErr := githubClient.client.Do(githubClient.ctx, req, nil)
-	if doErr != nil {
-		return doErr
+	// Delete the release (a release that is already gone counts as deleted)
+	_, err := githubClient.client.Repositories.DeleteRelease(ctx, owner, repo, release.GetID())
+	if err = WrapError(err); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
 	}
 
-	//log.Println("Delete release response:", res)
-
 	// Return nil on success
 	return nil
 }
 
-func (githubClient *GitHub) deleteTag(owner string, repo string, release *github.RepositoryRelease) error {
-	// Construct the API endpoint url
-	url := "https://api.github.com/repos/" + owner + "/" + repo + "/git/refs/tags/" + *release.TagName
-
-	//log.Println("Deleting tag:", url)
-
-	// Create the tag deletion request
-	req, reqErr := githubClient.client.NewRequest("DELETE", url, nil)
-	if reqErr != nil {
-		return reqErr
-	}
+// DeleteTag will attempt to delete a tag from GitHub (a tag that is already gone counts as deleted)
+func (githubClient *GitHub) DeleteTag(ctx context.Context, owner string, repo string, tag string) error {
+	//log.Println("Deleting tag:", tag)
 
-	// Run the request
-	_, doErr := githubClient.client.Do(githubClient.ctx, req, nil)
-	if doErr != nil {
-		return doErr
+	// Delete the tag reference
+	_, err := githubClient.client.Git.DeleteRef(ctx, owner, repo, "tags/"+tag)
+	if err = WrapError(err); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
 	}
 
-	//log.Println("Delete tag response:", res)
-
 	// Return nil on success
 	return nil
 }
 
-func (githubClient *GitHub) getAllReleases(owner string, repository string, page int, existingReleases []*github.RepositoryRelease) ([]*github.RepositoryRelease, error) {
-	//log.Println("Getting releases for page ", page)
-
-	// Create an array that will eventually contain all releases
-	allReleases := make([]*github.RepositoryRelease, 0)
-
-	// Use existing releases if necessary
-	if existingReleases != nil {
-		allReleases = existingReleases
+// GetTagSHA returns the SHA the tag points to (an ErrNotFound error if the tag doesn't exist)
+func (githubClient *GitHub) GetTagSHA(ctx context.Context, owner string, repo string, tag string) (string, error) {
+	ref, res, err := githubClient.client.Git.GetRef(ctx, owner, repo, "tags/"+tag)
+	if err != nil {
+		// Without an exact match, the API lists the tags starting with the name instead
+		if res != nil && res.StatusCode == http.StatusOK {
+			return "", &describedError{message: fmt.Sprintf("tag %q does not exist", tag), err: ErrNotFound}
+		}
+		return "", WrapError(err)
 	}
+	return ref.GetObject().GetSHA(), nil
+}
+
+// The API and upload paths of a GitHub Enterprise Server instance
+const (
+	enterpriseAPIPath    = "api/v3/"
+	enterpriseUploadPath = "api/uploads/"
+)
 
-	// Get releases for the current page
-	releases, res, err := githubClient.client.Repositories.ListReleases(githubClient.ctx, owner, repository, &github.ListOptions{Page: page, PerPage: 100})
+// enterpriseBaseURL normalizes the API base URL of a GitHub Enterprise Server instance,
+// adding the "api/v3/" path if it's missing (eg. https://ghe.example.com to https://ghe.example.com/api/v3/)
+func enterpriseBaseURL(baseURL string) (string, error) {
+	endpoint, err := url.Parse(baseURL)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-
-	// Add the current releases
-	for _, release := range releases {
-		allReleases = append(allReleases, release)
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return "", errors.New("invalid GitHub Enterprise Server URL \"" + baseURL + "\" (eg. https://ghe.example.com/api/v3/)")
 	}
-
-	// Recursively move to the next page if there are any more pages left
-	if res.NextPage > 0 && res.NextPage > page {
-		//log.Println("Moving from page", page, "to", res.NextPage)
-		return githubClient.getAllReleases(owner, repository, res.NextPage, allReleases)
+	if !strings.HasSuffix(endpoint.Path, "/") {
+		endpoint.Path += "/"
 	}
+	if !strings.HasSuffix(endpoint.Path, "/"+enterpriseAPIPath) {
+		endpoint.Path += enterpriseAPIPath
+	}
+	return endpoint.String(), nil
+}
 
-	// Return all releases if we're done
-	return allReleases, nil
+// enterpriseUploadURL derives the upload URL of a GitHub Enterprise Server instance from its normalized API base URL
+// (eg. https://ghe.example.com/api/v3/ to https://ghe.example.com/api/uploads/)
+func enterpriseUploadURL(baseURL string) string {
+	return strings.TrimSuffix(baseURL, enterpriseAPIPath) + enterpriseUploadPath
 }
@@ -1,7 +1,142 @@
 package ghapi
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v24/github"
+)
 
 func TestDummy(t *testing.T) {
 
 }
+
+func TestRemoveReleaseTreatsNotFoundAsDeleted(t *testing.T) {
+	paths := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, err := NewGitHub("token", WithEnterpriseURLs(server.URL+"/api/v3/", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, tag := int64(1), "v1.0.0"
+	if err := client.RemoveRelease(context.Background(), "owner", "repo", &github.RepositoryRelease{ID: &id, TagName: &tag}); err != nil {
+		t.Errorf("expected a missing release to count as deleted, got %v", err)
+	}
+
+	expected := []string{
+		"DELETE /api/v3/repos/owner/repo/releases/1",
+		"DELETE /api/v3/repos/owner/repo/git/refs/tags/v1.0.0",
+	}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected requests %v, got %v", expected, paths)
+	}
+}
+
+func TestEnterpriseURLs(t *testing.T) {
+	tests := map[string][2]string{
+		"https://ghe.example.com/api/v3/":     {"https://ghe.example.com/api/v3/", "https://ghe.example.com/api/uploads/"},
+		"https://ghe.example.com/api/v3":      {"https://ghe.example.com/api/v3/", "https://ghe.example.com/api/uploads/"},
+		"https://ghe.example.com/":            {"https://ghe.example.com/api/v3/", "https://ghe.example.com/api/uploads/"},
+		"https://ghe.example.com":             {"https://ghe.example.com/api/v3/", "https://ghe.example.com/api/uploads/"},
+		"https://example.com/github/api/v3/":  {"https://example.com/github/api/v3/", "https://example.com/github/api/uploads/"},
+		"http://ghe.example.com:8080/api/v3/": {"http://ghe.example.com:8080/api/v3/", "http://ghe.example.com:8080/api/uploads/"},
+	}
+	for input, expected := range tests {
+		baseURL, err := enterpriseBaseURL(input)
+		if err != nil {
+			t.Errorf("%s: %v", input, err)
+			continue
+		}
+		if uploadURL := enterpriseUploadURL(baseURL); baseURL != expected[0] || uploadURL != expected[1] {
+			t.Errorf("%s: expected %s and %s, got %s and %s", input, expected[0], expected[1], baseURL, uploadURL)
+		}
+	}
+	for _, input := range []string{"ghe.example.com", "https://ghe.example.com/%zz"} {
+		if _, err := enterpriseBaseURL(input); err == nil {
+			t.Errorf("expected %q to be rejected", input)
+		}
+	}
+
+	// Requests to a base URL without the API path are sent to the API path
+	paths := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	defer server.Close()
+	client, err := NewGitHub("token", WithEnterpriseURLs(server.URL, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Identity(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != "/api/v3/user" {
+		t.Errorf("expected a request to /api/v3/user, got %v", paths)
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	userAgents := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.UserAgent())
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	for _, userAgent := range []string{"", "githubby/1.2.3"} {
+		opts := []Option{WithEnterpriseURLs(server.URL+"/api/v3/", "")}
+		if userAgent != "" {
+			opts = append(opts, WithUserAgent(userAgent))
+		}
+		client, err := NewGitHub("token", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.RateLimits(context.Background())
+	}
+
+	expected := []string{DefaultUserAgent, "githubby/1.2.3"}
+	if strings.Join(userAgents, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected user agents %v, got %v", expected, userAgents)
+	}
+}
+
+func TestGetTagSHA(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/repos/owner/repo/git/refs/tags/v1.0.0":
+			w.Write([]byte(`{"ref":"refs/tags/v1.0.0","object":{"type":"commit","sha":"abc123"}}`))
+		case "/api/v3/repos/owner/repo/git/refs/tags/v1":
+			// Without an exact match, the tags starting with the name are listed
+			w.Write([]byte(`[{"ref":"refs/tags/v1.0.0","object":{"type":"commit","sha":"abc123"}}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewGitHub("token", WithEnterpriseURLs(server.URL+"/api/v3/", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sha, err := client.GetTagSHA(context.Background(), "owner", "repo", "v1.0.0"); err != nil || sha != "abc123" {
+		t.Errorf("expected abc123, got %q (%v)", sha, err)
+	}
+	for _, tag := range []string{"v1", "v2"} {
+		if _, err := client.GetTagSHA(context.Background(), "owner", "repo", tag); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected the tag not to be found, got %v", tag, err)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package ghapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TransportConfig configures the HTTP client used for API requests
+type TransportConfig struct {
+	// ProxyURL routes all requests through this proxy (defaults to the HTTPS_PROXY, HTTP_PROXY and NO_PROXY variables)
+	ProxyURL string
+
+	// CABundle is the path to a PEM file with certificates to trust in addition to the system ones
+	CABundle string
+
+	// Insecure disables TLS certificate verification (only meant for test instances of GitHub Enterprise Server)
+	Insecure bool
+
+	// ConnectTimeout limits how long establishing a connection (including the TLS handshake) may take (0 for the default)
+	ConnectTimeout time.Duration
+
+	// RequestTimeout limits how long a single request attempt may take, including reading its response (0 for no limit)
+	RequestTimeout time.Duration
+}
+
+// TraceEvent describes a single HTTP request attempt and its outcome
+type TraceEvent struct {
+	Method   string
+	URL      string
+	Status   int
+	Duration time.Duration
+
+	// RequestHeader holds the request headers, with any credentials redacted
+	RequestHeader http.Header
+
+	// RateLimit, RateLimitRemaining and RateLimitReset are the rate limit headers of the response (empty if missing)
+	RateLimit          string
+	RateLimitRemaining string
+	RateLimitReset     string
+
+	// Err is the error if no response was received
+	Err error
+}
+
+// TraceFunc is called after every HTTP request attempt (including retries) with its outcome
+type TraceFunc func(event TraceEvent)
+
+// The value credential headers are replaced with in traces
+const redacted = "REDACTED"
+
+// The request headers that carry credentials
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization"}
+
+// The default time allowed for establishing a connection
+const defaultConnectTimeout = 30 * time.Second
+
+// WithTransport configures the HTTP client used for API requests (proxy, TLS and timeouts)
+func WithTransport(config TransportConfig) Option {
+	return func(opts *options) {
+		opts.transport = config
+	}
+}
+
+// WithTrace calls the trace function after every HTTP request attempt, for diagnosing connectivity issues
+func WithTrace(trace TraceFunc) Option {
+	return func(opts *options) {
+		opts.trace = trace
+	}
+}
+
+// NewHTTPClient creates an HTTP client for requests outside of the GitHub API (eg. webhooks),
+// using the same proxy, TLS and connection settings as the API requests
+func NewHTTPClient(config TransportConfig, timeout time.Duration) (*http.Client, error) {
+	transport, err := config.newTransport()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// newTransport creates the HTTP transport all API requests are sent with
+func (config TransportConfig) newTransport() (http.RoundTripper, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if config.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.ProxyURL)
+		if err != nil {
+			return nil, errors.New("invalid proxy URL")
+		}
+		if proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q (expected eg. http://proxy.example.com:3128)", redactURL(config.ProxyURL))
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	connectTimeout := config.ConnectTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = defaultConnectTimeout
+	}
+	transport.DialContext = (&net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+
+	if config.CABundle != "" || config.Insecure {
+		tlsConfig := &tls.Config{InsecureSkipVerify: config.Insecure}
+		if config.CABundle != "" {
+			rootCAs, err := loadCABundle(config.CABundle)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = rootCAs
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	if config.RequestTimeout > 0 {
+		return &timeoutTransport{base: transport, timeout: config.RequestTimeout}, nil
+	}
+	return transport, nil
+}
+
+// timeoutTransport is a http.RoundTripper that limits how long each request attempt may take,
+// until its response body is closed
+type timeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+// RoundTrip executes a single HTTP transaction, cancelling it (and any read of its response body) once the timeout passes
+func (transport *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), transport.timeout)
+	res, err := transport.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, transport.wrapError(ctx, err)
+	}
+	res.Body = &timeoutBody{ReadCloser: res.Body, ctx: ctx, cancel: cancel, transport: transport}
+	return res, nil
+}
+
+// wrapError reports errors caused by the timeout as such (keeping them recognizable as timeouts)
+func (transport *timeoutTransport) wrapError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("request timeout of %s exceeded: %w", transport.timeout, err)
+	}
+	return err
+}
+
+// timeoutBody is a response body whose reads fail once the timeout of its request passes
+type timeoutBody struct {
+	io.ReadCloser
+	ctx       context.Context
+	cancel    context.CancelFunc
+	transport *timeoutTransport
+}
+
+// Read reads from the response body, reporting an error caused by the timeout as such
+func (body *timeoutBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = body.transport.wrapError(body.ctx, err)
+	}
+	return n, err
+}
+
+// Close closes the response body, releasing the timeout
+func (body *timeoutBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.cancel()
+	return err
+}
+
+// loadCABundle returns the system certificate pool extended with the certificates of a PEM file
+func loadCABundle(path string) (*x509.CertPool, error) {
+	bundle, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the CA bundle: %w", err)
+	}
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil || rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if !rootCAs.AppendCertsFromPEM(bundle) {
+		return nil, errors.New("the CA bundle " + path + " contains no PEM encoded certificates")
+	}
+	return rootCAs, nil
+}
+
+// traceTransport is a http.RoundTripper that reports every request attempt to a trace function
+type traceTransport struct {
+	base  http.RoundTripper
+	trace TraceFunc
+}
+
+// RoundTrip executes a single HTTP transaction, reporting its outcome once the response headers arrive
+func (transport *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := transport.base.RoundTrip(req)
+
+	event := TraceEvent{
+		Method:        req.Method,
+		URL:           redactURL(req.URL.String()),
+		Duration:      time.Since(start),
+		RequestHeader: redactHeader(req.Header),
+		Err:           err,
+	}
+	if res != nil {
+		event.Status = res.StatusCode
+		event.RateLimit = res.Header.Get("X-RateLimit-Limit")
+		event.RateLimitRemaining = res.Header.Get("X-RateLimit-Remaining")
+		event.RateLimitReset = res.Header.Get("X-RateLimit-Reset")
+	}
+	transport.trace(event)
+
+	return res, err
+}
+
+// redactHeader returns a copy of the headers with any credentials redacted
+func redactHeader(header http.Header) http.Header {
+	redactedHeader := header.Clone()
+	for _, key := range credentialHeaders {
+		if redactedHeader.Get(key) != "" {
+			redactedHeader.Set(key, redacted)
+		}
+	}
+	return redactedHeader
+}
+
+// redactURL removes any password from a URL (eg. a proxy URL with credentials)
+func redactURL(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || parsedURL.User == nil {
+		return rawURL
+	}
+	if _, hasPassword := parsedURL.User.Password(); hasPassword {
+		parsedURL.User = url.UserPassword(parsedURL.User.Username(), redacted)
+	}
+	return parsedURL.String()
+}
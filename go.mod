@@ -24,6 +24,7 @@ require (
 	google.golang.org/appengine v1.6.4 // indirect
 	gopkg.in/cheggaaa/pb.v1 v1.0.28
 	gopkg.in/yaml.v2 v2.2.3
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 go 1.13
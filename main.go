@@ -3,10 +3,17 @@ package main
 
 import (
 	"github.com/Didstopia/githubby/cmd"
-	_ "github.com/joho/godotenv/autoload"
+)
+
+// The build metadata, injected at build time (eg. -ldflags "-X main.Version=1.2.3")
+var (
+	Version   = ""
+	Commit    = ""
+	BuildDate = ""
 )
 
 // The main function's sole purpose is to pass execution to the primary command
 func main() {
+	cmd.SetVersion(Version, Commit, BuildDate)
 	cmd.Execute()
 }
@@ -0,0 +1,111 @@
+package util
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FindGitRemoteURL finds the git repository containing the supplied directory (walking up its parents)
+// and returns the URL of the named remote, following worktrees and submodules to their shared config
+func FindGitRemoteURL(dir string, remote string) (string, error) {
+	gitDir, err := findGitDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	// Worktrees keep their config in the common git directory
+	configDir := gitDir
+	if data, err := ioutil.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		configDir = resolvePath(gitDir, strings.TrimSpace(string(data)))
+	}
+
+	file, err := os.Open(filepath.Join(configDir, "config"))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	remoteURL := ""
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		// Track the current section, eg. [remote "origin"]
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(strings.Trim(line, "[]"))
+			continue
+		}
+		if section != "remote \""+remote+"\"" {
+			continue
+		}
+
+		// Find the (first) url of the remote
+		separatorIndex := strings.Index(line, "=")
+		if separatorIndex == -1 {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(line[:separatorIndex]), "url") && remoteURL == "" {
+			remoteURL = strings.Trim(strings.TrimSpace(line[separatorIndex+1:]), "\"")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if remoteURL == "" {
+		return "", errors.New("git remote \"" + remote + "\" not found in " + filepath.Join(configDir, "config"))
+	}
+	return remoteURL, nil
+}
+
+// findGitDir walks up from the supplied directory until it finds a ".git" directory,
+// or a ".git" file pointing to one (as used by worktrees and submodules)
+func findGitDir(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		gitPath := filepath.Join(dir, ".git")
+		if info, err := os.Stat(gitPath); err == nil {
+			if info.IsDir() {
+				return gitPath, nil
+			}
+
+			// Parse the "gitdir: <path>" reference
+			data, err := ioutil.ReadFile(gitPath)
+			if err != nil {
+				return "", err
+			}
+			content := strings.TrimSpace(string(data))
+			if !strings.HasPrefix(content, "gitdir:") {
+				return "", errors.New("invalid .git file at " + gitPath)
+			}
+			return resolvePath(dir, strings.TrimSpace(strings.TrimPrefix(content, "gitdir:"))), nil
+		}
+
+		// Move on to the parent directory, stopping at the root
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("not inside a git repository")
+		}
+		dir = parent
+	}
+}
+
+// resolvePath resolves a possibly relative path against the supplied base directory
+func resolvePath(base string, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(base, path)
+}
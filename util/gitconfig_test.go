@@ -0,0 +1,80 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testGitConfig = `[core]
+	bare = false
+[remote "origin"]
+	url = git@github.com:user/repo.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+[remote "upstream"]
+	url = https://github.com/upstream/repo
+`
+
+func createTestGitRepository(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "githubby")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "repo", ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "repo", ".git", "config"), []byte(testGitConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestFindGitRemoteURL(t *testing.T) {
+	dir := createTestGitRepository(t)
+	defer os.RemoveAll(dir)
+
+	// Search from a nested directory
+	nested := filepath.Join(dir, "repo", "some", "nested", "dir")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if url, err := FindGitRemoteURL(nested, "origin"); err != nil || url != "git@github.com:user/repo.git" {
+		t.Errorf("expected the origin url, got %q (%v)", url, err)
+	}
+	if url, err := FindGitRemoteURL(nested, "upstream"); err != nil || url != "https://github.com/upstream/repo" {
+		t.Errorf("expected the upstream url, got %q (%v)", url, err)
+	}
+	if _, err := FindGitRemoteURL(nested, "missing"); err == nil {
+		t.Error("expected an error for a missing remote")
+	}
+	if _, err := FindGitRemoteURL(dir, "origin"); err == nil {
+		t.Error("expected an error outside of a git repository")
+	}
+}
+
+func TestFindGitRemoteURLInWorktree(t *testing.T) {
+	dir := createTestGitRepository(t)
+	defer os.RemoveAll(dir)
+
+	// Set up a worktree the same way git does
+	worktreeGitDir := filepath.Join(dir, "repo", ".git", "worktrees", "feature")
+	if err := os.MkdirAll(worktreeGitDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(worktreeGitDir, "commondir"), []byte("../..\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	worktree := filepath.Join(dir, "feature")
+	if err := os.MkdirAll(worktree, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+worktreeGitDir+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if url, err := FindGitRemoteURL(worktree, "origin"); err != nil || url != "git@github.com:user/repo.git" {
+		t.Errorf("expected the origin url, got %q (%v)", url, err)
+	}
+}
@@ -0,0 +1,166 @@
+package util
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// DefaultHost is the host of github.com repositories
+const DefaultHost = "github.com"
+
+// GitHub's naming rules for owners (users and organizations) and repositories
+var (
+	ownerPattern      = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9_]|-[A-Za-z0-9_])*$`)
+	repositoryPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+)
+
+// The maximum lengths GitHub allows for owner and repository names
+const (
+	maxOwnerLength      = 39
+	maxRepositoryLength = 100
+)
+
+// Repository is a parsed reference to a GitHub repository
+type Repository struct {
+	// Host is the GitHub host the repository lives on (empty if the reference didn't include one)
+	Host string
+
+	// Owner is the user or organization owning the repository
+	Owner string
+
+	// Name is the name of the repository
+	Name string
+}
+
+// FullName returns the repository in the short owner/repo format
+func (repository *Repository) FullName() string {
+	return repository.Owner + "/" + repository.Name
+}
+
+// String returns the repository in the host/owner/repo format, or owner/repo if it has no host
+func (repository *Repository) String() string {
+	if repository.Host == "" {
+		return repository.FullName()
+	}
+	return repository.Host + "/" + repository.FullName()
+}
+
+// ParseRepository parses a repository reference in any of the common formats:
+//
+//	owner/repo
+//	host/owner/repo
+//	https://host/owner/repo(.git) (including browser URLs pointing into the repository)
+//	https://api.github.com/repos/owner/repo and https://host/api/v3/repos/owner/repo
+//	git@host:owner/repo(.git)
+//	ssh://git@host(:port)/owner/repo(.git), git://host/owner/repo(.git)
+func ParseRepository(reference string) (*Repository, error) {
+	invalid := errors.New("supplied repository \"" + reference + "\" is not a valid GitHub repository (eg. user/repo, https://github.com/user/repo or git@github.com:user/repo.git)")
+
+	reference = strings.TrimSpace(reference)
+	host := ""
+	path := ""
+
+	switch {
+	case strings.Contains(reference, "://"):
+		// URLs (https, http, ssh, git, git+ssh)
+		parsedURL, err := url.Parse(reference)
+		if err != nil || parsedURL.Hostname() == "" {
+			return nil, invalid
+		}
+		switch parsedURL.Scheme {
+		case "https", "http", "ssh", "git", "git+ssh", "ssh+git":
+		default:
+			return nil, invalid
+		}
+		host = parsedURL.Hostname()
+		path = strings.Trim(parsedURL.Path, "/")
+
+		// API URLs point at the repository below a "repos" prefix
+		if host == "api."+DefaultHost {
+			host = DefaultHost
+			if !strings.HasPrefix(path, "repos/") {
+				return nil, invalid
+			}
+			path = strings.TrimPrefix(path, "repos/")
+		} else if strings.HasPrefix(path, "api/v3/repos/") {
+			path = strings.TrimPrefix(path, "api/v3/repos/")
+		}
+
+		// Browser URLs for http(s) may point anywhere inside the repository
+		if parsedURL.Scheme == "https" || parsedURL.Scheme == "http" {
+			if segments := strings.Split(path, "/"); len(segments) > 2 {
+				path = strings.Join(segments[:2], "/")
+			}
+		}
+	case strings.Contains(reference, "@") && strings.Contains(reference, ":"):
+		// SCP-like SSH references (git@host:owner/repo.git)
+		separatorIndex := strings.Index(reference, ":")
+		host = reference[strings.Index(reference, "@")+1 : separatorIndex]
+		path = strings.Trim(reference[separatorIndex+1:], "/")
+	default:
+		// Short references, optionally prefixed with a host
+		path = strings.Trim(reference, "/")
+		if segments := strings.Split(path, "/"); len(segments) == 3 && isHost(segments[0]) {
+			host = segments[0]
+			path = strings.Join(segments[1:], "/")
+		}
+	}
+
+	// What's left must be exactly owner/repo
+	segments := strings.Split(strings.TrimSuffix(path, ".git"), "/")
+	if len(segments) != 2 {
+		return nil, invalid
+	}
+	repository := &Repository{
+		Host:  normalizeHost(host),
+		Owner: segments[0],
+		Name:  segments[1],
+	}
+
+	// Validate the owner and repository names
+	if err := validateNames(repository); err != nil {
+		return nil, errors.New(invalid.Error() + ": " + err.Error())
+	}
+
+	return repository, nil
+}
+
+// validateNames checks the owner and repository names against GitHub's naming rules
+func validateNames(repository *Repository) error {
+	if len(repository.Owner) > maxOwnerLength || !ownerPattern.MatchString(repository.Owner) {
+		return errors.New("owner names may only contain alphanumeric characters or single hyphens, cannot begin or end with a hyphen and are at most 39 characters long")
+	}
+	if len(repository.Name) > maxRepositoryLength || !repositoryPattern.MatchString(repository.Name) || repository.Name == "." || repository.Name == ".." {
+		return errors.New("repository names may only contain alphanumeric characters, hyphens, underscores and periods and are at most 100 characters long")
+	}
+	return nil
+}
+
+// isHost checks if the supplied path segment looks like a host name rather than an owner
+func isHost(segment string) bool {
+	return strings.Contains(segment, ".") || strings.Contains(segment, ":") || segment == "localhost"
+}
+
+// normalizeHost lowercases the host and maps github.com aliases to github.com itself
+func normalizeHost(host string) string {
+	host = strings.ToLower(host)
+	if host == "www."+DefaultHost || host == "ssh."+DefaultHost {
+		return DefaultHost
+	}
+	return host
+}
+
+// SameHost checks if two hosts refer to the same GitHub instance (ignoring case, ports and aliases)
+func SameHost(a string, b string) bool {
+	return normalizeHost(stripPort(a)) == normalizeHost(stripPort(b))
+}
+
+// stripPort removes an optional port from a host
+func stripPort(host string) string {
+	if index := strings.LastIndex(host, ":"); index != -1 && !strings.Contains(host[index:], "]") {
+		return host[:index]
+	}
+	return host
+}
@@ -0,0 +1,72 @@
+package util
+
+import "testing"
+
+func TestParseRepository(t *testing.T) {
+	tests := map[string]Repository{
+		"user/repo":                                        {"", "user", "repo"},
+		" user/repo.js ":                                   {"", "user", "repo.js"},
+		"github.com/user/repo":                             {"github.com", "user", "repo"},
+		"ghe.example.com/user/repo":                        {"ghe.example.com", "user", "repo"},
+		"https://github.com/user/repo":                     {"github.com", "user", "repo"},
+		"https://www.github.com/user/repo/":                {"github.com", "user", "repo"},
+		"https://github.com/user/repo.git":                 {"github.com", "user", "repo"},
+		"https://github.com/user/repo/releases/tag/v1.0.0": {"github.com", "user", "repo"},
+		"http://ghe.example.com/user/repo":                 {"ghe.example.com", "user", "repo"},
+		"https://api.github.com/repos/user/repo":           {"github.com", "user", "repo"},
+		"https://ghe.example.com/api/v3/repos/user/repo":   {"ghe.example.com", "user", "repo"},
+		"git@github.com:user/repo.git":                     {"github.com", "user", "repo"},
+		"git@ghe.example.com:user/repo":                    {"ghe.example.com", "user", "repo"},
+		"ssh://git@github.com/user/repo.git":               {"github.com", "user", "repo"},
+		"ssh://git@ghe.example.com:2222/user/repo.git":     {"ghe.example.com", "user", "repo"},
+		"git://github.com/user/repo.git":                   {"github.com", "user", "repo"},
+		"Some-User/Some_Repo.v2":                           {"", "Some-User", "Some_Repo.v2"},
+	}
+	for reference, expected := range tests {
+		repository, err := ParseRepository(reference)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", reference, err)
+			continue
+		}
+		if *repository != expected {
+			t.Errorf("%s: expected %+v, got %+v", reference, expected, *repository)
+		}
+	}
+}
+
+func TestParseRepositoryRejectsInvalidReferences(t *testing.T) {
+	invalid := []string{
+		"",
+		"user",
+		"user/",
+		"/repo",
+		"user/repo/extra",
+		"-user/repo",
+		"user-/repo",
+		"us--er/repo",
+		"user/re po",
+		"user/..",
+		"a-very-long-owner-name-that-exceeds-the-limit/repo",
+		"ftp://github.com/user/repo",
+		"https://github.com/user",
+		"https://api.github.com/user/repo",
+		"git@github.com:user",
+	}
+	for _, reference := range invalid {
+		if repository, err := ParseRepository(reference); err == nil {
+			t.Errorf("%s: expected an error, got %+v", reference, *repository)
+		}
+	}
+}
+
+func TestSameHost(t *testing.T) {
+	if !SameHost("GitHub.com", "www.github.com") {
+		t.Error("expected github.com aliases to match")
+	}
+	if !SameHost("ghe.example.com:8443", "ghe.example.com") {
+		t.Error("expected ports to be ignored")
+	}
+	if SameHost("ghe.example.com", "github.com") {
+		t.Error("expected different hosts not to match")
+	}
+}
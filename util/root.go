@@ -3,37 +3,34 @@ package util
 
 import (
 	"errors"
-	"strings"
 )
 
-// ValidateGitHubRepository will check if the supplied repository is a valid GitHub repository (supports short format only, eg. user/repo)
+// ValidateGitHubRepository will check if the supplied repository is a valid github.com repository
+// (in any of the formats supported by ParseRepository, eg. user/repo or https://github.com/user/repo)
 func ValidateGitHubRepository(repository string) (string, string, error) {
+	return ValidateEnterpriseRepository(repository, DefaultHost)
+}
+
+// ValidateEnterpriseRepository works like ValidateGitHubRepository, but for repositories on the supplied
+// GitHub Enterprise Server host (eg. ghe.example.com/user/repo or git@ghe.example.com:user/repo.git)
+func ValidateEnterpriseRepository(repository string, host string) (string, string, error) {
 	//log.Println("Validating repository string:", repository)
 
-	// Validates that the repository string length is at least 3 characters
-	if len(repository) < 3 {
-		return "", "", errors.New("supplied repository \"" + repository + "\" string is not a valid GitHub repository (short format only, eg. user/repo)")
+	if host == "" {
+		host = DefaultHost
 	}
 
-	// Start with some empty values
-	parsedOwner := ""
-	parsedRepo := ""
-
-	// Check that the repository string is in the correct format
-	if strings.Contains(repository, "://") || strings.Contains(repository, "github.com") || strings.Count(repository, "/") != 1 {
-		return "", "", errors.New("supplied repository \"" + repository + "\" string is not a valid GitHub repository (short format only, eg. user/repo)")
+	// Parse the "owner" and "repo"
+	parsed, err := ParseRepository(repository)
+	if err != nil {
+		return "", "", err
 	}
 
-	// Parse the owner and repository
-	separatorIndex := strings.Index(repository, "/")
-	parsedOwner = repository[0:separatorIndex]
-	parsedRepo = repository[separatorIndex+1 : len(repository)]
-
-	// Validate the owner and repository
-	if len(parsedOwner) < 1 || len(parsedRepo) < 1 || strings.Contains(parsedOwner, "/") || strings.Contains(parsedRepo, "/") {
-		return "", "", errors.New("supplied repository \"" + repository + "\" string is not a valid GitHub repository (short format only, eg. user/repo)")
+	// Validate that the repository lives on the expected host
+	if parsed.Host != "" && !SameHost(parsed.Host, host) {
+		return "", "", errors.New("supplied repository \"" + repository + "\" belongs to " + parsed.Host + " instead of " + host + " (use --base-url to target a GitHub Enterprise Server)")
 	}
 
 	// Return the parsed "owner" and "repo" on success
-	return parsedOwner, parsedRepo, nil
+	return parsed.Owner, parsed.Name, nil
 }
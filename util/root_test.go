@@ -5,3 +5,47 @@ import "testing"
 func TestDummy(t *testing.T) {
 
 }
+
+func TestValidateEnterpriseRepository(t *testing.T) {
+	valid := []string{
+		"user/repo",
+		"ghe.example.com/user/repo",
+		"https://ghe.example.com/user/repo",
+		"https://ghe.example.com/user/repo/",
+	}
+	for _, repository := range valid {
+		owner, repo, err := ValidateEnterpriseRepository(repository, "ghe.example.com")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", repository, err)
+			continue
+		}
+		if owner != "user" || repo != "repo" {
+			t.Errorf("%s: expected user/repo, got %s/%s", repository, owner, repo)
+		}
+	}
+
+	invalid := []string{
+		"other.example.com/user/repo",
+		"https://github.com/user/repo",
+		"ghe.example.com/user",
+	}
+	for _, repository := range invalid {
+		if _, _, err := ValidateEnterpriseRepository(repository, "ghe.example.com"); err == nil {
+			t.Errorf("%s: expected an error", repository)
+		}
+	}
+}
+
+func TestValidateGitHubRepository(t *testing.T) {
+	owner, repo, err := ValidateGitHubRepository("git@github.com:user/repo.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner != "user" || repo != "repo" {
+		t.Errorf("expected user/repo, got %s/%s", owner, repo)
+	}
+
+	if _, _, err := ValidateGitHubRepository("https://ghe.example.com/user/repo"); err == nil {
+		t.Error("expected an error for an enterprise repository")
+	}
+}